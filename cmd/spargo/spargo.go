@@ -7,6 +7,7 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"regexp"
 	"strings"
 
 	"github.com/ross-spencer/spargo/pkg/spargo"
@@ -82,6 +83,16 @@ func runQuery(sparqlFile string) {
 
 	sparqlMe := spargo.SPARQLClient{}
 	sparqlMe.ClientInit(url, queryString)
+
+	if isGraphQuery(queryString) {
+		graph, err := sparqlMe.SPARQLGraph()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Query failed with: %+s\n\n", err)
+		}
+		fmt.Print(graph)
+		return
+	}
+
 	res, err := sparqlMe.SPARQLGo()
 
 	if err != nil {
@@ -91,6 +102,22 @@ func runQuery(sparqlFile string) {
 	fmt.Println(res)
 }
 
+// graphQuery matches the start of a CONSTRUCT or DESCRIBE query once
+// comments have been removed, allowing for PREFIX and BASE declarations.
+var graphQuery = regexp.MustCompile(`(?is)^\s*((PREFIX|BASE)\s[^>]*>\s*)*(CONSTRUCT|DESCRIBE)\b`)
+
+// isGraphQuery reports whether a query will return an RDF graph rather
+// than SPARQL results.
+func isGraphQuery(queryString string) bool {
+	var lines []string
+	for _, line := range strings.Split(queryString, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "#") {
+			lines = append(lines, line)
+		}
+	}
+	return graphQuery.MatchString(strings.Join(lines, "\n"))
+}
+
 func isPipeInput() bool {
 	info, err := os.Stdin.Stat()
 	if err != nil {
@@ -0,0 +1,161 @@
+package builder
+
+import (
+	"testing"
+)
+
+// TestSelect builds a query similar to examples/006-puids-in-wikidata
+// and checks the output.
+func TestSelect(t *testing.T) {
+	uri, puid, label := Var("uri"), Var("puid"), Var("uriLabel")
+	q := Select(uri, label, puid).
+		Distinct().
+		Prefix("wd", "http://www.wikidata.org/entity/").
+		Prefix("wdt", "http://www.wikidata.org/prop/direct/").
+		Where(
+			Triple(uri, Seq(Prefixed("wdt", "P31"), ZeroOrMore(Prefixed("wdt", "P279"))), Prefixed("wd", "Q235557")),
+			Optional(Triple(uri, Prefixed("wdt", "P2748"), puid)),
+			Filter(Or(Eq(puid, String("fmt/134")), Not(Bound(puid)))),
+			Values([]Term{puid}, []Term{String("fmt/134")}, []Term{nil}),
+			Service(Prefixed("wikibase", "label"),
+				Triple(Prefixed("bd", "serviceParam"), Prefixed("wikibase", "language"), String("en")),
+			),
+		).
+		OrderBy(Asc(uri), Desc(puid)).
+		Limit(10).
+		Offset(20)
+
+	expected := `PREFIX wd: <http://www.wikidata.org/entity/>
+PREFIX wdt: <http://www.wikidata.org/prop/direct/>
+SELECT DISTINCT ?uri ?uriLabel ?puid
+WHERE {
+  ?uri wdt:P31/wdt:P279* wd:Q235557 .
+  OPTIONAL {
+    ?uri wdt:P2748 ?puid .
+  }
+  FILTER((?puid = "fmt/134") || (!BOUND(?puid)))
+  VALUES ?puid { "fmt/134" UNDEF }
+  SERVICE wikibase:label {
+    bd:serviceParam wikibase:language "en" .
+  }
+}
+ORDER BY ?uri DESC(?puid)
+LIMIT 10
+OFFSET 20
+`
+	built, err := q.Build()
+	if err != nil {
+		t.Fatalf("Unexpected error building query: %s", err)
+	}
+	if built != expected {
+		t.Errorf("Unexpected query:\n%s\nExpected:\n%s", built, expected)
+	}
+	if q.String() != expected {
+		t.Errorf("String() should match Build()")
+	}
+}
+
+// TestAskAndConstruct checks the other query forms.
+func TestAskAndConstruct(t *testing.T) {
+	ask := Ask().
+		Where(Triple(Var("x"), IRI("http://www.wikidata.org/prop/direct/P2748"), String("fmt/134")))
+	expectedAsk := `ASK
+WHERE {
+  ?x <http://www.wikidata.org/prop/direct/P2748> "fmt/134" .
+}
+`
+	if ask.String() != expectedAsk {
+		t.Errorf("Unexpected ASK query:\n%s", ask)
+	}
+
+	construct := Construct(Triple(Var("s"), A, IRI("http://example.com/Format"))).
+		Prefix("xsd", "http://www.w3.org/2001/XMLSchema#").
+		Where(
+			Triple(Var("s"), IRI("http://example.com/offset"), Typed("4", Prefixed("xsd", "integer"))),
+			Triple(Var("s"), IRI("http://example.com/label"), LangString(`Say "cheese"`, "en-GB")),
+			Triple(Var("s"), IRI("http://example.com/size"), Int(-5)),
+		).
+		Limit(5)
+	expectedConstruct := `PREFIX xsd: <http://www.w3.org/2001/XMLSchema#>
+CONSTRUCT {
+  ?s a <http://example.com/Format> .
+}
+WHERE {
+  ?s <http://example.com/offset> "4"^^xsd:integer .
+  ?s <http://example.com/label> "Say \"cheese\""@en-GB .
+  ?s <http://example.com/size> -5 .
+}
+LIMIT 5
+`
+	if construct.String() != expectedConstruct {
+		t.Errorf("Unexpected CONSTRUCT query:\n%s", construct)
+	}
+}
+
+// TestExpressions checks how operators and function calls are written.
+func TestExpressions(t *testing.T) {
+	x, y := Var("x"), Var("y")
+	expressions := map[string]Expr{
+		"?x":                    And(x),
+		"?x = ?y":               Or(Eq(x, y)),
+		"?x && ?y":              And(x, y),
+		"(?x = ?y) || (!?x)":    Or(Eq(x, y), Not(x)),
+		"!(?x && ?y)":           Not(And(x, y)),
+		"!(?x)":                 Not(And(x)),
+		"CONCAT(?x, \"a\", ?y)": Call("concat", x, String("a"), y),
+		"RAND()":                Call("RAND"),
+		"BOUND(?x)":             Bound(x),
+	}
+	for expected, expr := range expressions {
+		rendered, err := expr.renderExpr()
+		if err != nil {
+			t.Errorf("Unexpected error rendering '%s': %s", expected, err)
+		}
+		if rendered != expected {
+			t.Errorf("Expected '%s', received '%s'", expected, rendered)
+		}
+	}
+}
+
+// TestInvalidQueries makes sure malformed parts are reported rather
+// than written into the query.
+func TestInvalidQueries(t *testing.T) {
+	invalid := []*SelectQuery{
+		Select(Var("bad name")).Where(),
+		Select(IRI("http://example.com")).Where(),
+		Select().Where(Triple(Var("s"), IRI("http://example.com/> . } DROP ALL #"), Var("o"))),
+		Select().Where(Triple(Var("s"), Prefixed("wdt", "P31 ; "), Var("o"))),
+		Select().Where(Triple(Var("s"), IRI("relative/path"), Var("o"))),
+		Select().Where(Triple(Var("s"), Var("p"), LangString("x", "en; DROP"))),
+		Select().Where(Triple(Var("s"), Var("p"), Typed("x", String("not a datatype")))),
+		Select().Where(Triple(Seq(Prefixed("wdt", "P31")), Var("p"), Var("o"))),
+		Select().Where(Triple(Var("s"), Var("p"), nil)),
+		Select().Where(Values([]Term{Var("a"), Var("b")}, []Term{String("x")})),
+		Select().Where(Union([]Pattern{Triple(Var("s"), Var("p"), Var("o"))})),
+		Select().Where(Filter(Call("DROP ALL", Var("x")))),
+		Select().Where(Filter(Call("FOO"))),
+		Select().Where(Filter(And())),
+		Select().Where(Filter(Eq(Var("x"), A))),
+		Select().Where(Bind(A, Var("x"))),
+		Select().OrderBy(Asc(A)),
+		Select().Where(Filter(Call("BOUND"))),
+		Select().Where(Filter(Call("BOUND", String("x")))),
+		Select().Where(Filter(Call("IF", Var("x"), Var("y")))),
+		Select().Where(Filter(Call("RAND", Var("x")))),
+		Select().Where(Triple(Var("s"), String("p"), Var("o"))),
+		Select().Where(Triple(Var("s"), Int(5), BlankNode("x"))),
+		Select().Where(Triple(Var("s"), BlankNode("p"), Var("o"))),
+		Select().Where(Triple(Var("s"), Bool(true), Var("o"))),
+		Select().Limit(-1),
+		Select().Prefix("bad prefix", "http://example.com/"),
+	}
+	for _, q := range invalid {
+		built, err := q.Build()
+		if err == nil {
+			t.Errorf("Expected an error building query:\n%s", built)
+		}
+		if q.String() != "" {
+			t.Errorf("Expected an empty string for an invalid query, received:\n%s", q.String())
+		}
+	}
+}
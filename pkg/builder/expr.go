@@ -0,0 +1,212 @@
+package builder
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Expr is an expression that can be used in a FILTER, BIND or ORDER BY.
+// Every Term is also an Expr.
+type Expr interface {
+	renderExpr() (string, error)
+}
+
+// operation is a unary or binary operator applied to expressions.
+// Binary operators given a single operand, e.g. And(x), render the
+// operand alone.
+type operation struct {
+	operator string
+	operands []Expr
+	prefix   bool
+}
+
+// renderOperand renders an operand, bracketing nested operations so
+// that precedence is never in doubt.
+func renderOperand(operand Expr) (string, error) {
+	if operand == nil {
+		return "", fmt.Errorf("builder: missing operand")
+	}
+	value, err := operand.renderExpr()
+	if err != nil {
+		return "", err
+	}
+	if _, nested := operand.(operation); nested {
+		return "(" + value + ")", nil
+	}
+	return value, nil
+}
+
+func (op operation) renderExpr() (string, error) {
+	if len(op.operands) == 1 && !op.prefix {
+		if op.operands[0] == nil {
+			return "", fmt.Errorf("builder: missing operand")
+		}
+		return op.operands[0].renderExpr()
+	}
+	rendered := make([]string, 0, len(op.operands))
+	for _, operand := range op.operands {
+		value, err := renderOperand(operand)
+		if err != nil {
+			return "", err
+		}
+		rendered = append(rendered, value)
+	}
+	if len(rendered) == 0 {
+		return "", fmt.Errorf("builder: '%s' needs operands", op.operator)
+	}
+	if op.prefix {
+		return op.operator + rendered[0], nil
+	}
+	return strings.Join(rendered, " "+op.operator+" "), nil
+}
+
+// Eq returns the expression left = right.
+func Eq(left Expr, right Expr) Expr {
+	return operation{operator: "=", operands: []Expr{left, right}}
+}
+
+// Ne returns the expression left != right.
+func Ne(left Expr, right Expr) Expr {
+	return operation{operator: "!=", operands: []Expr{left, right}}
+}
+
+// Lt returns the expression left < right.
+func Lt(left Expr, right Expr) Expr {
+	return operation{operator: "<", operands: []Expr{left, right}}
+}
+
+// Le returns the expression left <= right.
+func Le(left Expr, right Expr) Expr {
+	return operation{operator: "<=", operands: []Expr{left, right}}
+}
+
+// Gt returns the expression left > right.
+func Gt(left Expr, right Expr) Expr {
+	return operation{operator: ">", operands: []Expr{left, right}}
+}
+
+// Ge returns the expression left >= right.
+func Ge(left Expr, right Expr) Expr {
+	return operation{operator: ">=", operands: []Expr{left, right}}
+}
+
+// And returns the logical conjunction of its operands.
+func And(operands ...Expr) Expr {
+	return operation{operator: "&&", operands: operands}
+}
+
+// Or returns the logical disjunction of its operands.
+func Or(operands ...Expr) Expr {
+	return operation{operator: "||", operands: operands}
+}
+
+// Not returns the logical negation of an expression.
+func Not(operand Expr) Expr {
+	return operation{operator: "!", operands: []Expr{operand}, prefix: true}
+}
+
+// call is a call to a built-in function.
+type call struct {
+	name string
+	args []Expr
+}
+
+// arity is the least and most arguments a built-in function takes. A
+// maximum of -1 means there is no limit.
+type arity struct {
+	min int
+	max int
+}
+
+// builtins are the SPARQL 1.1 built-in functions that take expressions
+// as arguments, and the number of arguments they take. EXISTS and NOT
+// EXISTS take patterns and aren't included.
+var builtins = map[string]arity{
+	"STR": {1, 1}, "LANG": {1, 1}, "LANGMATCHES": {2, 2}, "DATATYPE": {1, 1},
+	"BOUND": {1, 1}, "IRI": {1, 1}, "URI": {1, 1}, "BNODE": {0, 1}, "RAND": {0, 0},
+	"ABS": {1, 1}, "CEIL": {1, 1}, "FLOOR": {1, 1}, "ROUND": {1, 1}, "CONCAT": {0, -1},
+	"STRLEN": {1, 1}, "UCASE": {1, 1}, "LCASE": {1, 1}, "ENCODE_FOR_URI": {1, 1},
+	"CONTAINS": {2, 2}, "STRSTARTS": {2, 2}, "STRENDS": {2, 2}, "STRBEFORE": {2, 2},
+	"STRAFTER": {2, 2}, "YEAR": {1, 1}, "MONTH": {1, 1}, "DAY": {1, 1}, "HOURS": {1, 1},
+	"MINUTES": {1, 1}, "SECONDS": {1, 1}, "TIMEZONE": {1, 1}, "TZ": {1, 1},
+	"NOW": {0, 0}, "UUID": {0, 0}, "STRUUID": {0, 0}, "MD5": {1, 1}, "SHA1": {1, 1},
+	"SHA256": {1, 1}, "SHA384": {1, 1}, "SHA512": {1, 1}, "COALESCE": {0, -1},
+	"IF": {3, 3}, "STRLANG": {2, 2}, "STRDT": {2, 2}, "SAMETERM": {2, 2},
+	"ISIRI": {1, 1}, "ISURI": {1, 1}, "ISBLANK": {1, 1}, "ISLITERAL": {1, 1},
+	"ISNUMERIC": {1, 1}, "REGEX": {2, 3}, "SUBSTR": {2, 3}, "REPLACE": {3, 4},
+}
+
+// Call returns a call to a SPARQL built-in function, e.g.
+// Call("STRLEN", Var("label")). The name is checked against the
+// built-in functions of SPARQL 1.1, ignoring case, along with the
+// number of arguments the function takes.
+func Call(name string, args ...Expr) Expr {
+	return call{name: name, args: args}
+}
+
+func (fn call) renderExpr() (string, error) {
+	name := strings.ToUpper(fn.name)
+	expected, ok := builtins[name]
+	if !ok {
+		return "", fmt.Errorf("builder: invalid function name: '%s'", fn.name)
+	}
+	if len(fn.args) < expected.min || (expected.max >= 0 && len(fn.args) > expected.max) {
+		return "", fmt.Errorf("builder: wrong number of arguments to %s: %d", name, len(fn.args))
+	}
+	// BOUND is the only function whose argument must be a variable.
+	if name == "BOUND" {
+		if _, isVar := fn.args[0].(variable); !isVar {
+			return "", fmt.Errorf("builder: BOUND takes a variable")
+		}
+	}
+	rendered := make([]string, 0, len(fn.args))
+	for _, arg := range fn.args {
+		if arg == nil {
+			return "", fmt.Errorf("builder: missing argument to %s", name)
+		}
+		value, err := arg.renderExpr()
+		if err != nil {
+			return "", err
+		}
+		rendered = append(rendered, value)
+	}
+	return name + "(" + strings.Join(rendered, ", ") + ")", nil
+}
+
+// Bound returns BOUND(?v).
+func Bound(v Term) Expr {
+	return Call("BOUND", v)
+}
+
+// Lang returns LANG(expr).
+func Lang(expr Expr) Expr {
+	return Call("LANG", expr)
+}
+
+// LangMatches returns LANGMATCHES(expr, String(lang)).
+func LangMatches(expr Expr, lang string) Expr {
+	return Call("LANGMATCHES", expr, String(lang))
+}
+
+// Str returns STR(expr).
+func Str(expr Expr) Expr {
+	return Call("STR", expr)
+}
+
+// Contains returns CONTAINS(expr, String(value)).
+func Contains(expr Expr, value string) Expr {
+	return Call("CONTAINS", expr, String(value))
+}
+
+// StrStarts returns STRSTARTS(expr, String(value)).
+func StrStarts(expr Expr, value string) Expr {
+	return Call("STRSTARTS", expr, String(value))
+}
+
+// Regex returns REGEX(expr, pattern, flags). flags may be empty.
+func Regex(expr Expr, pattern string, flags string) Expr {
+	if flags == "" {
+		return Call("REGEX", expr, String(pattern))
+	}
+	return Call("REGEX", expr, String(pattern), String(flags))
+}
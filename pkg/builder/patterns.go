@@ -0,0 +1,296 @@
+package builder
+
+import (
+	"fmt"
+	"strings"
+)
+
+// indentation used for each level of nesting in a query.
+const indentation = "  "
+
+// Pattern is part of a group graph pattern, e.g. a triple pattern,
+// OPTIONAL block or FILTER.
+type Pattern interface {
+	renderPattern(depth int) (string, error)
+}
+
+// renderGroup renders patterns within braces at the given depth.
+func renderGroup(patterns []Pattern, depth int) (string, error) {
+	var builder strings.Builder
+	builder.WriteString("{\n")
+	for _, pattern := range patterns {
+		if pattern == nil {
+			return "", fmt.Errorf("builder: missing pattern")
+		}
+		rendered, err := pattern.renderPattern(depth + 1)
+		if err != nil {
+			return "", err
+		}
+		builder.WriteString(strings.Repeat(indentation, depth+1))
+		builder.WriteString(rendered)
+		builder.WriteString("\n")
+	}
+	builder.WriteString(strings.Repeat(indentation, depth))
+	builder.WriteString("}")
+	return builder.String(), nil
+}
+
+// TriplePattern is a single subject, predicate, object pattern.
+type TriplePattern struct {
+	Subject   Term
+	Predicate Term
+	Object    Term
+}
+
+// Triple returns a triple pattern. The predicate may be a property path.
+func Triple(subject Term, predicate Term, object Term) TriplePattern {
+	return TriplePattern{Subject: subject, Predicate: predicate, Object: object}
+}
+
+func (triple TriplePattern) renderPattern(depth int) (string, error) {
+	rendered := make([]string, 0, 3)
+	for i, term := range []Term{triple.Subject, triple.Predicate, triple.Object} {
+		if term == nil {
+			return "", fmt.Errorf("builder: incomplete triple pattern")
+		}
+		if _, isPath := term.(path); isPath && i != 1 {
+			return "", fmt.Errorf("builder: property paths can only be used as predicates")
+		}
+		if word, isKeyword := term.(keyword); isKeyword && word == A && i != 1 {
+			return "", fmt.Errorf("builder: 'a' can only be used as a predicate")
+		}
+		if i == 1 && !validPredicate(term) {
+			return "", fmt.Errorf("builder: a predicate must be a variable, IRI, prefixed name, 'a' or property path")
+		}
+		value, err := term.renderTerm()
+		if err != nil {
+			return "", err
+		}
+		rendered = append(rendered, value)
+	}
+	return strings.Join(rendered, " ") + " .", nil
+}
+
+// validPredicate reports whether a term can be used as a predicate.
+// Literals and blank nodes cannot.
+func validPredicate(term Term) bool {
+	switch value := term.(type) {
+	case variable, iri, prefixed, path:
+		return true
+	case keyword:
+		return value == A
+	}
+	return false
+}
+
+// group is a nested group graph pattern.
+type group struct {
+	keyword  string
+	patterns []Pattern
+}
+
+// Group returns a nested group of patterns, { ... }.
+func Group(patterns ...Pattern) Pattern {
+	return group{patterns: patterns}
+}
+
+// Optional returns an OPTIONAL block.
+func Optional(patterns ...Pattern) Pattern {
+	return group{keyword: "OPTIONAL", patterns: patterns}
+}
+
+// Minus returns a MINUS block.
+func Minus(patterns ...Pattern) Pattern {
+	return group{keyword: "MINUS", patterns: patterns}
+}
+
+func (block group) renderPattern(depth int) (string, error) {
+	rendered, err := renderGroup(block.patterns, depth)
+	if err != nil {
+		return "", err
+	}
+	if block.keyword == "" {
+		return rendered, nil
+	}
+	return block.keyword + " " + rendered, nil
+}
+
+// union is a UNION of groups.
+type union struct {
+	groups [][]Pattern
+}
+
+// Union returns the union of several groups of patterns, e.g.
+// Union([]Pattern{...}, []Pattern{...}).
+func Union(groups ...[]Pattern) Pattern {
+	return union{groups: groups}
+}
+
+func (u union) renderPattern(depth int) (string, error) {
+	if len(u.groups) < 2 {
+		return "", fmt.Errorf("builder: UNION needs at least two groups")
+	}
+	rendered := make([]string, 0, len(u.groups))
+	for _, patterns := range u.groups {
+		value, err := renderGroup(patterns, depth)
+		if err != nil {
+			return "", err
+		}
+		rendered = append(rendered, value)
+	}
+	return strings.Join(rendered, " UNION "), nil
+}
+
+// filter is a FILTER constraint.
+type filter struct {
+	expr Expr
+}
+
+// Filter returns a FILTER constraint on the group.
+func Filter(expr Expr) Pattern {
+	return filter{expr: expr}
+}
+
+func (f filter) renderPattern(depth int) (string, error) {
+	if f.expr == nil {
+		return "", fmt.Errorf("builder: empty FILTER")
+	}
+	value, err := f.expr.renderExpr()
+	if err != nil {
+		return "", err
+	}
+	return "FILTER(" + value + ")", nil
+}
+
+// bind is a BIND assignment.
+type bind struct {
+	expr Expr
+	v    Term
+}
+
+// Bind returns BIND(expr AS ?v).
+func Bind(expr Expr, v Term) Pattern {
+	return bind{expr: expr, v: v}
+}
+
+func (b bind) renderPattern(depth int) (string, error) {
+	if _, ok := b.v.(variable); !ok {
+		return "", fmt.Errorf("builder: BIND must assign to a variable")
+	}
+	if b.expr == nil {
+		return "", fmt.Errorf("builder: empty BIND")
+	}
+	value, err := b.expr.renderExpr()
+	if err != nil {
+		return "", err
+	}
+	name, err := b.v.renderTerm()
+	if err != nil {
+		return "", err
+	}
+	return "BIND(" + value + " AS " + name + ")", nil
+}
+
+// values is an inline VALUES block.
+type values struct {
+	vars []Term
+	rows [][]Term
+}
+
+// Values returns an inline VALUES block binding vars to each of rows. A
+// nil term in a row is written as UNDEF.
+func Values(vars []Term, rows ...[]Term) Pattern {
+	return values{vars: vars, rows: rows}
+}
+
+func (block values) renderPattern(depth int) (string, error) {
+	if len(block.vars) == 0 {
+		return "", fmt.Errorf("builder: VALUES needs at least one variable")
+	}
+	names := make([]string, 0, len(block.vars))
+	for _, v := range block.vars {
+		if _, ok := v.(variable); !ok {
+			return "", fmt.Errorf("builder: VALUES can only bind variables")
+		}
+		name, err := v.renderTerm()
+		if err != nil {
+			return "", err
+		}
+		names = append(names, name)
+	}
+	single := len(names) == 1
+	rows := make([]string, 0, len(block.rows))
+	for _, row := range block.rows {
+		if len(row) != len(names) {
+			return "", fmt.Errorf("builder: VALUES row has %d terms for %d variables", len(row), len(names))
+		}
+		terms := make([]string, 0, len(row))
+		for _, term := range row {
+			if term == nil {
+				terms = append(terms, "UNDEF")
+				continue
+			}
+			switch term.(type) {
+			case variable, path, blank:
+				return "", fmt.Errorf("builder: VALUES can only contain IRIs and literals")
+			}
+			if term == A {
+				return "", fmt.Errorf("builder: VALUES can only contain IRIs and literals")
+			}
+			value, err := term.renderTerm()
+			if err != nil {
+				return "", err
+			}
+			terms = append(terms, value)
+		}
+		if single {
+			rows = append(rows, terms[0])
+		} else {
+			rows = append(rows, "("+strings.Join(terms, " ")+")")
+		}
+	}
+	if single {
+		return "VALUES " + names[0] + " { " + strings.Join(rows, " ") + " }", nil
+	}
+	return "VALUES (" + strings.Join(names, " ") + ") { " + strings.Join(rows, " ") + " }", nil
+}
+
+// service is a SERVICE block for a federated query.
+type service struct {
+	endpoint Term
+	silent   bool
+	patterns []Pattern
+}
+
+// Service returns a SERVICE block that evaluates patterns at another
+// endpoint, e.g. Wikidata's wikibase:label service.
+func Service(endpoint Term, patterns ...Pattern) Pattern {
+	return service{endpoint: endpoint, patterns: patterns}
+}
+
+// ServiceSilent returns a SERVICE SILENT block, which ignores failures
+// at the other endpoint.
+func ServiceSilent(endpoint Term, patterns ...Pattern) Pattern {
+	return service{endpoint: endpoint, silent: true, patterns: patterns}
+}
+
+func (block service) renderPattern(depth int) (string, error) {
+	switch block.endpoint.(type) {
+	case iri, prefixed, variable:
+	default:
+		return "", fmt.Errorf("builder: SERVICE needs an IRI or variable")
+	}
+	endpoint, err := block.endpoint.renderTerm()
+	if err != nil {
+		return "", err
+	}
+	rendered, err := renderGroup(block.patterns, depth)
+	if err != nil {
+		return "", err
+	}
+	keyword := "SERVICE "
+	if block.silent {
+		keyword = "SERVICE SILENT "
+	}
+	return keyword + endpoint + " " + rendered, nil
+}
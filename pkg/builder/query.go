@@ -0,0 +1,359 @@
+package builder
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Ordering is a condition in an ORDER BY clause.
+type Ordering struct {
+	expr       Expr
+	descending bool
+}
+
+// Asc orders results by expr in ascending order.
+func Asc(expr Expr) Ordering {
+	return Ordering{expr: expr}
+}
+
+// Desc orders results by expr in descending order.
+func Desc(expr Expr) Ordering {
+	return Ordering{expr: expr, descending: true}
+}
+
+// render writes out the ordering condition.
+func (order Ordering) render() (string, error) {
+	if order.expr == nil {
+		return "", fmt.Errorf("builder: empty ORDER BY condition")
+	}
+	value, err := order.expr.renderExpr()
+	if err != nil {
+		return "", err
+	}
+	if order.descending {
+		return "DESC(" + value + ")", nil
+	}
+	if _, isVar := order.expr.(variable); isVar {
+		return value, nil
+	}
+	return "ASC(" + value + ")", nil
+}
+
+// prefix is a PREFIX declaration.
+type prefix struct {
+	name string
+	iri  string
+}
+
+// query holds the parts common to each query form.
+type query struct {
+	prefixes []prefix
+	where    []Pattern
+	order    []Ordering
+	limit    int
+	offset   int
+}
+
+// newQuery returns a query with no limit or offset.
+func newQuery() query {
+	return query{limit: -1, offset: -1}
+}
+
+// addPrefix declares a prefix, replacing any earlier declaration.
+func (q *query) addPrefix(name string, iri string) {
+	for i, existing := range q.prefixes {
+		if existing.name == name {
+			q.prefixes[i].iri = iri
+			return
+		}
+	}
+	q.prefixes = append(q.prefixes, prefix{name: name, iri: iri})
+}
+
+// renderPrologue writes out the PREFIX declarations.
+func (q *query) renderPrologue(builder *strings.Builder) error {
+	for _, declaration := range q.prefixes {
+		if !prefixName.MatchString(declaration.name) {
+			return fmt.Errorf("builder: invalid prefix: '%s'", declaration.name)
+		}
+		namespace, err := IRI(declaration.iri).renderTerm()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(builder, "PREFIX %s: %s\n", declaration.name, namespace)
+	}
+	return nil
+}
+
+// renderWhere writes out the WHERE clause.
+func (q *query) renderWhere(builder *strings.Builder) error {
+	where, err := renderGroup(q.where, 0)
+	if err != nil {
+		return err
+	}
+	builder.WriteString("WHERE ")
+	builder.WriteString(where)
+	builder.WriteString("\n")
+	return nil
+}
+
+// renderModifiers writes out ORDER BY, LIMIT and OFFSET.
+func (q *query) renderModifiers(builder *strings.Builder) error {
+	if len(q.order) > 0 {
+		conditions := make([]string, 0, len(q.order))
+		for _, order := range q.order {
+			condition, err := order.render()
+			if err != nil {
+				return err
+			}
+			conditions = append(conditions, condition)
+		}
+		builder.WriteString("ORDER BY " + strings.Join(conditions, " ") + "\n")
+	}
+	if q.limit >= 0 {
+		builder.WriteString("LIMIT " + strconv.Itoa(q.limit) + "\n")
+	}
+	if q.offset >= 0 {
+		builder.WriteString("OFFSET " + strconv.Itoa(q.offset) + "\n")
+	}
+	return nil
+}
+
+// checkRange reports a negative LIMIT or OFFSET.
+func checkRange(name string, value int) error {
+	if value < 0 {
+		return fmt.Errorf("builder: %s cannot be negative: %d", name, value)
+	}
+	return nil
+}
+
+// SelectQuery builds a SELECT query.
+type SelectQuery struct {
+	query
+	vars     []Term
+	distinct bool
+	err      error
+}
+
+// Select starts a SELECT query projecting the given variables. With no
+// variables, SELECT * is used.
+func Select(vars ...Term) *SelectQuery {
+	return &SelectQuery{query: newQuery(), vars: vars}
+}
+
+// Distinct removes duplicate rows from the results.
+func (q *SelectQuery) Distinct() *SelectQuery {
+	q.distinct = true
+	return q
+}
+
+// Prefix declares a prefix for use in prefixed names.
+func (q *SelectQuery) Prefix(name string, iri string) *SelectQuery {
+	q.addPrefix(name, iri)
+	return q
+}
+
+// Where adds patterns to the WHERE clause.
+func (q *SelectQuery) Where(patterns ...Pattern) *SelectQuery {
+	q.where = append(q.where, patterns...)
+	return q
+}
+
+// OrderBy adds conditions to the ORDER BY clause.
+func (q *SelectQuery) OrderBy(orders ...Ordering) *SelectQuery {
+	q.order = append(q.order, orders...)
+	return q
+}
+
+// Limit sets the maximum number of results.
+func (q *SelectQuery) Limit(limit int) *SelectQuery {
+	if err := checkRange("LIMIT", limit); err != nil && q.err == nil {
+		q.err = err
+	}
+	q.limit = limit
+	return q
+}
+
+// Offset sets the number of results to skip.
+func (q *SelectQuery) Offset(offset int) *SelectQuery {
+	if err := checkRange("OFFSET", offset); err != nil && q.err == nil {
+		q.err = err
+	}
+	q.offset = offset
+	return q
+}
+
+// Build returns the query, or the first problem found building it.
+func (q *SelectQuery) Build() (string, error) {
+	if q.err != nil {
+		return "", q.err
+	}
+	var builder strings.Builder
+	if err := q.renderPrologue(&builder); err != nil {
+		return "", err
+	}
+	builder.WriteString("SELECT ")
+	if q.distinct {
+		builder.WriteString("DISTINCT ")
+	}
+	if len(q.vars) == 0 {
+		builder.WriteString("*")
+	}
+	for i, v := range q.vars {
+		if _, ok := v.(variable); !ok {
+			return "", fmt.Errorf("builder: SELECT can only project variables")
+		}
+		name, err := v.renderTerm()
+		if err != nil {
+			return "", err
+		}
+		if i > 0 {
+			builder.WriteString(" ")
+		}
+		builder.WriteString(name)
+	}
+	builder.WriteString("\n")
+	if err := q.renderWhere(&builder); err != nil {
+		return "", err
+	}
+	if err := q.renderModifiers(&builder); err != nil {
+		return "", err
+	}
+	return builder.String(), nil
+}
+
+// String returns the query for use with SPARQLClient.SetQuery. An empty
+// string is returned if the query cannot be built, see Build.
+func (q *SelectQuery) String() string {
+	built, _ := q.Build()
+	return built
+}
+
+// AskQuery builds an ASK query.
+type AskQuery struct {
+	query
+}
+
+// Ask starts an ASK query.
+func Ask() *AskQuery {
+	return &AskQuery{query: newQuery()}
+}
+
+// Prefix declares a prefix for use in prefixed names.
+func (q *AskQuery) Prefix(name string, iri string) *AskQuery {
+	q.addPrefix(name, iri)
+	return q
+}
+
+// Where adds patterns to the WHERE clause.
+func (q *AskQuery) Where(patterns ...Pattern) *AskQuery {
+	q.where = append(q.where, patterns...)
+	return q
+}
+
+// Build returns the query, or the first problem found building it.
+func (q *AskQuery) Build() (string, error) {
+	var builder strings.Builder
+	if err := q.renderPrologue(&builder); err != nil {
+		return "", err
+	}
+	builder.WriteString("ASK\n")
+	if err := q.renderWhere(&builder); err != nil {
+		return "", err
+	}
+	return builder.String(), nil
+}
+
+// String returns the query for use with SPARQLClient.SetQuery. An empty
+// string is returned if the query cannot be built, see Build.
+func (q *AskQuery) String() string {
+	built, _ := q.Build()
+	return built
+}
+
+// ConstructQuery builds a CONSTRUCT query.
+type ConstructQuery struct {
+	query
+	template []TriplePattern
+	err      error
+}
+
+// Construct starts a CONSTRUCT query that returns a graph built from
+// the template for each solution.
+func Construct(template ...TriplePattern) *ConstructQuery {
+	return &ConstructQuery{query: newQuery(), template: template}
+}
+
+// Prefix declares a prefix for use in prefixed names.
+func (q *ConstructQuery) Prefix(name string, iri string) *ConstructQuery {
+	q.addPrefix(name, iri)
+	return q
+}
+
+// Where adds patterns to the WHERE clause.
+func (q *ConstructQuery) Where(patterns ...Pattern) *ConstructQuery {
+	q.where = append(q.where, patterns...)
+	return q
+}
+
+// OrderBy adds conditions to the ORDER BY clause.
+func (q *ConstructQuery) OrderBy(orders ...Ordering) *ConstructQuery {
+	q.order = append(q.order, orders...)
+	return q
+}
+
+// Limit sets the maximum number of solutions used to build the graph.
+func (q *ConstructQuery) Limit(limit int) *ConstructQuery {
+	if err := checkRange("LIMIT", limit); err != nil && q.err == nil {
+		q.err = err
+	}
+	q.limit = limit
+	return q
+}
+
+// Offset sets the number of solutions to skip.
+func (q *ConstructQuery) Offset(offset int) *ConstructQuery {
+	if err := checkRange("OFFSET", offset); err != nil && q.err == nil {
+		q.err = err
+	}
+	q.offset = offset
+	return q
+}
+
+// Build returns the query, or the first problem found building it.
+func (q *ConstructQuery) Build() (string, error) {
+	if q.err != nil {
+		return "", q.err
+	}
+	var builder strings.Builder
+	if err := q.renderPrologue(&builder); err != nil {
+		return "", err
+	}
+	patterns := make([]Pattern, 0, len(q.template))
+	for _, triple := range q.template {
+		if _, isPath := triple.Predicate.(path); isPath {
+			return "", fmt.Errorf("builder: property paths cannot be used in a CONSTRUCT template")
+		}
+		patterns = append(patterns, triple)
+	}
+	template, err := renderGroup(patterns, 0)
+	if err != nil {
+		return "", err
+	}
+	builder.WriteString("CONSTRUCT " + template + "\n")
+	if err := q.renderWhere(&builder); err != nil {
+		return "", err
+	}
+	if err := q.renderModifiers(&builder); err != nil {
+		return "", err
+	}
+	return builder.String(), nil
+}
+
+// String returns the query for use with SPARQLClient.SetQuery. An empty
+// string is returned if the query cannot be built, see Build.
+func (q *ConstructQuery) String() string {
+	built, _ := q.Build()
+	return built
+}
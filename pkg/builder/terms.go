@@ -0,0 +1,314 @@
+/* SPARQL query builder
+
+Package builder constructs SPARQL queries from typed parts so that the
+resulting query string is always well-formed. Variables, IRIs, prefixed
+names and literals are validated, and literals are escaped, as they are
+written out.
+
+	q := builder.Select(builder.Var("uri"), builder.Var("puid")).
+		Distinct().
+		Prefix("wdt", "http://www.wikidata.org/prop/direct/").
+		Prefix("wd", "http://www.wikidata.org/entity/").
+		Where(
+			builder.Triple(builder.Var("uri"), builder.Prefixed("wdt", "P31"), builder.Prefixed("wd", "Q235557")),
+			builder.Optional(
+				builder.Triple(builder.Var("uri"), builder.Prefixed("wdt", "P2748"), builder.Var("puid")),
+			),
+		).
+		OrderBy(builder.Asc(builder.Var("uri"))).
+		Limit(10)
+
+	sparqlMe.SetQuery(q.String())
+
+*/
+
+package builder
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ross-spencer/spargo/pkg/spargo"
+)
+
+// Term is an RDF term, variable or property path that can appear in a
+// triple pattern. Terms can only be created using the functions in this
+// package.
+type Term interface {
+	Expr
+	renderTerm() (string, error)
+}
+
+// Validation of the names that appear in a query.
+var (
+	varName    = regexp.MustCompile(`^[\p{L}\p{N}_][\p{L}\p{N}_\x{00B7}\x{0300}-\x{036F}\x{203F}-\x{2040}]*$`)
+	prefixName = regexp.MustCompile(`^([\p{L}]([\p{L}\p{N}_.\-]*[\p{L}\p{N}_\-])?)?$`)
+	localName  = regexp.MustCompile(`^(([\p{L}\p{N}_:]|%[0-9A-Fa-f]{2})(([\p{L}\p{N}_:.\-]|%[0-9A-Fa-f]{2})*([\p{L}\p{N}_:\-]|%[0-9A-Fa-f]{2}))?)?$`)
+)
+
+// variable is a SPARQL variable.
+type variable string
+
+// Var returns the variable with the given name, e.g. Var("puid") for
+// ?puid. A leading ? or $ is ignored.
+func Var(name string) Term {
+	return variable(strings.TrimLeft(name, "?$"))
+}
+
+func (v variable) renderTerm() (string, error) {
+	if !varName.MatchString(string(v)) {
+		return "", fmt.Errorf("builder: invalid variable name: '%s'", string(v))
+	}
+	return "?" + string(v), nil
+}
+
+func (v variable) renderExpr() (string, error) {
+	return v.renderTerm()
+}
+
+// iri is a full IRI.
+type iri string
+
+// IRI returns a term for a full IRI, e.g.
+// IRI("http://www.wikidata.org/entity/Q235557"). The IRI must be
+// absolute.
+func IRI(value string) Term {
+	return iri(value)
+}
+
+func (value iri) renderTerm() (string, error) {
+	if err := spargo.ValidateIRI(string(value)); err != nil {
+		return "", err
+	}
+	return "<" + string(value) + ">", nil
+}
+
+func (value iri) renderExpr() (string, error) {
+	return value.renderTerm()
+}
+
+// prefixed is a prefixed name such as wdt:P31.
+type prefixed struct {
+	prefix string
+	local  string
+}
+
+// Prefixed returns a term for a prefixed name, e.g. Prefixed("wdt",
+// "P31") for wdt:P31. The prefix should be declared using the Prefix
+// method of the query unless the endpoint declares it.
+func Prefixed(prefix string, local string) Term {
+	return prefixed{prefix: prefix, local: local}
+}
+
+func (name prefixed) renderTerm() (string, error) {
+	if !prefixName.MatchString(name.prefix) {
+		return "", fmt.Errorf("builder: invalid prefix: '%s'", name.prefix)
+	}
+	if !localName.MatchString(name.local) {
+		return "", fmt.Errorf("builder: invalid local name: '%s'", name.local)
+	}
+	return name.prefix + ":" + name.local, nil
+}
+
+func (name prefixed) renderExpr() (string, error) {
+	return name.renderTerm()
+}
+
+// keyword is a term written as-is, e.g. 'a' or a boolean.
+type keyword string
+
+// A is the 'a' keyword, shorthand for rdf:type, for use as a predicate.
+var A Term = keyword("a")
+
+func (word keyword) renderTerm() (string, error) {
+	return string(word), nil
+}
+
+func (word keyword) renderExpr() (string, error) {
+	if word == A {
+		return "", fmt.Errorf("builder: 'a' can only be used as a predicate")
+	}
+	return string(word), nil
+}
+
+// blank is a labelled blank node.
+type blank string
+
+// BlankNode returns a term for a labelled blank node, e.g. _:b0.
+func BlankNode(label string) Term {
+	return blank(label)
+}
+
+func (label blank) renderTerm() (string, error) {
+	if !varName.MatchString(string(label)) {
+		return "", fmt.Errorf("builder: invalid blank node label: '%s'", string(label))
+	}
+	return "_:" + string(label), nil
+}
+
+func (label blank) renderExpr() (string, error) {
+	return label.renderTerm()
+}
+
+// literal is an RDF literal.
+type literal struct {
+	value    string
+	lang     string
+	datatype Term
+}
+
+// String returns a term for a simple string literal.
+func String(value string) Term {
+	return literal{value: value}
+}
+
+// LangString returns a term for a string literal with a language tag,
+// e.g. LangString("JPEG 2000", "en").
+func LangString(value string, lang string) Term {
+	return literal{value: value, lang: lang}
+}
+
+// Typed returns a term for a literal with a datatype, which must be an
+// IRI or prefixed name, e.g. Typed("5", Prefixed("xsd", "int")).
+func Typed(value string, datatype Term) Term {
+	return literal{value: value, datatype: datatype}
+}
+
+func (value literal) renderTerm() (string, error) {
+	quoted := spargo.Literal{Value: value.value}.String()
+	if value.lang != "" {
+		if err := spargo.ValidateLangTag(value.lang); err != nil {
+			return "", err
+		}
+		return quoted + "@" + value.lang, nil
+	}
+	if value.datatype != nil {
+		switch value.datatype.(type) {
+		case iri, prefixed:
+		default:
+			return "", fmt.Errorf("builder: datatype must be an IRI or prefixed name")
+		}
+		datatype, err := value.datatype.renderTerm()
+		if err != nil {
+			return "", err
+		}
+		return quoted + "^^" + datatype, nil
+	}
+	return quoted, nil
+}
+
+func (value literal) renderExpr() (string, error) {
+	return value.renderTerm()
+}
+
+// Int returns a term for an xsd:integer literal.
+func Int(value int64) Term {
+	return keyword(strconv.FormatInt(value, 10))
+}
+
+// Float returns a term for an xsd:double literal.
+func Float(value float64) Term {
+	switch {
+	case math.IsInf(value, 1):
+		return literal{value: "INF", datatype: iri(spargo.XSDDouble)}
+	case math.IsInf(value, -1):
+		return literal{value: "-INF", datatype: iri(spargo.XSDDouble)}
+	case math.IsNaN(value):
+		return literal{value: "NaN", datatype: iri(spargo.XSDDouble)}
+	}
+	return keyword(strconv.FormatFloat(value, 'E', -1, 64))
+}
+
+// Bool returns a term for an xsd:boolean literal.
+func Bool(value bool) Term {
+	return keyword(strconv.FormatBool(value))
+}
+
+// path is a SPARQL property path.
+type path struct {
+	operator string
+	parts    []Term
+}
+
+// Seq returns a sequence path, e.g. wdt:P31/wdt:P279.
+func Seq(parts ...Term) Term {
+	return path{operator: "/", parts: parts}
+}
+
+// Alt returns an alternative path, e.g. rdfs:label|skos:altLabel.
+func Alt(parts ...Term) Term {
+	return path{operator: "|", parts: parts}
+}
+
+// ZeroOrMore returns a path matching zero or more occurrences, e.g.
+// wdt:P279*.
+func ZeroOrMore(part Term) Term {
+	return path{operator: "*", parts: []Term{part}}
+}
+
+// OneOrMore returns a path matching one or more occurrences.
+func OneOrMore(part Term) Term {
+	return path{operator: "+", parts: []Term{part}}
+}
+
+// ZeroOrOne returns a path matching zero or one occurrences.
+func ZeroOrOne(part Term) Term {
+	return path{operator: "?", parts: []Term{part}}
+}
+
+// Inverse returns an inverse path, e.g. ^wdt:P31.
+func Inverse(part Term) Term {
+	return path{operator: "^", parts: []Term{part}}
+}
+
+func (p path) renderTerm() (string, error) {
+	if len(p.parts) == 0 {
+		return "", fmt.Errorf("builder: empty property path")
+	}
+	rendered := make([]string, 0, len(p.parts))
+	for _, part := range p.parts {
+		switch part.(type) {
+		case iri, prefixed, keyword, path:
+		default:
+			return "", fmt.Errorf("builder: property paths can only contain IRIs")
+		}
+		if word, ok := part.(keyword); ok && word != A {
+			return "", fmt.Errorf("builder: property paths can only contain IRIs")
+		}
+		value, err := part.renderTerm()
+		if err != nil {
+			return "", err
+		}
+		if nested, ok := part.(path); ok && nested.needsBrackets(p.operator) {
+			value = "(" + value + ")"
+		}
+		rendered = append(rendered, value)
+	}
+	switch p.operator {
+	case "/", "|":
+		return strings.Join(rendered, p.operator), nil
+	case "^":
+		return "^" + rendered[0], nil
+	}
+	return rendered[0] + p.operator, nil
+}
+
+// needsBrackets reports whether a path must be bracketed when nested
+// inside a path using the parent operator.
+func (p path) needsBrackets(parent string) bool {
+	switch p.operator {
+	case "/", "|":
+		return true
+	case "^":
+		return parent != "/" && parent != "|"
+	}
+	// Postfix operators cannot be applied twice without brackets.
+	return parent == "*" || parent == "+" || parent == "?"
+}
+
+func (p path) renderExpr() (string, error) {
+	return "", fmt.Errorf("builder: property paths cannot be used in expressions")
+}
@@ -0,0 +1,54 @@
+package spargo
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+// askTests describes a row of data for testing ASK queries with.
+type askTests struct {
+	responseValue string
+	expected      bool
+	expectedErr   error
+}
+
+var askResults = []askTests{
+	askTests{testAskTrue, true, nil},
+	askTests{testAskFalse, false, nil},
+	askTests{testString, false, ErrNotBoolean},
+	askTests{testEmptyResult, false, ErrNotBoolean},
+}
+
+// TestAsk makes sure the boolean result of an ASK query is returned,
+// and that false can be told apart from a response with no boolean.
+func TestAsk(t *testing.T) {
+	for _, val := range askResults {
+		httpClient := NewTestClient(func(req *http.Request) *http.Response {
+			return &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(bytes.NewBufferString(val.responseValue)),
+				Header:     make(http.Header),
+			}
+		})
+
+		sparql := SPARQLClient{}
+		sparql.Client = httpClient
+		sparql.ClientInit("http://example.com", `ASK { ?x <http://www.wikidata.org/prop/direct/P2748> "fmt/134" }`)
+
+		answer, err := sparql.Ask()
+		if !errors.Is(err, val.expectedErr) {
+			t.Errorf("Expected error '%v' from Ask, received: '%v'", val.expectedErr, err)
+		}
+		if answer != val.expected {
+			t.Errorf("Expected %t from Ask, received %t", val.expected, answer)
+		}
+
+		res, _ := sparql.SPARQLGo()
+		if res.IsBoolean() != (val.expectedErr == nil) {
+			t.Errorf("Unexpected IsBoolean() %t for response: %s", res.IsBoolean(), val.responseValue)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package spargo
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// redacted replaces credentials in debug output.
+const redacted string = "[redacted]"
+
+// Authenticator adds credentials to the requests a client sends.
+type Authenticator interface {
+	Authenticate(req *http.Request) error
+}
+
+// Refresher is implemented by authenticators whose credentials can be
+// renewed. If a request is rejected with 401 Unauthorized the client
+// calls Refresh and, if it reports that the credentials were renewed,
+// tries the request once more.
+type Refresher interface {
+	Refresh(ctx context.Context) (bool, error)
+}
+
+// BasicAuth authenticates requests using HTTP Basic authentication.
+type BasicAuth struct {
+	Username string
+	Password string
+}
+
+// Authenticate adds the username and password to a request.
+func (auth BasicAuth) Authenticate(req *http.Request) error {
+	req.SetBasicAuth(auth.Username, auth.Password)
+	return nil
+}
+
+// String describes the credentials without revealing the password.
+func (auth BasicAuth) String() string {
+	return fmt.Sprintf("BasicAuth{Username: %s, Password: %s}", auth.Username, redacted)
+}
+
+// GoString describes the credentials without revealing the password.
+func (auth BasicAuth) GoString() string {
+	return auth.String()
+}
+
+// TokenSource returns a new bearer token, e.g. by calling an OAuth
+// token endpoint.
+type TokenSource func(ctx context.Context) (string, error)
+
+// BearerAuth authenticates requests using a bearer token. A BearerAuth
+// is safe for concurrent use and can be shared between SPARQLClients.
+type BearerAuth struct {
+	mutex   sync.Mutex
+	token   string
+	refresh TokenSource
+}
+
+// NewBearerAuth returns a BearerAuth that sends token, fetching a new
+// one from refresh, if it isn't nil, when the token is rejected. If
+// token is empty the first token is fetched from refresh.
+func NewBearerAuth(token string, refresh TokenSource) *BearerAuth {
+	return &BearerAuth{token: token, refresh: refresh}
+}
+
+// Authenticate adds the bearer token to a request.
+func (auth *BearerAuth) Authenticate(req *http.Request) error {
+	auth.mutex.Lock()
+	token := auth.token
+	auth.mutex.Unlock()
+	if token == "" && auth.refresh != nil {
+		if _, err := auth.Refresh(req.Context()); err != nil {
+			return err
+		}
+		auth.mutex.Lock()
+		token = auth.token
+		auth.mutex.Unlock()
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// Refresh fetches a new token. It reports false if there is no way to
+// do so.
+func (auth *BearerAuth) Refresh(ctx context.Context) (bool, error) {
+	if auth.refresh == nil {
+		return false, nil
+	}
+	token, err := auth.refresh(ctx)
+	if err != nil {
+		return false, fmt.Errorf("spargo: refreshing bearer token: %w", err)
+	}
+	auth.mutex.Lock()
+	auth.token = token
+	auth.mutex.Unlock()
+	return true, nil
+}
+
+// String describes the authenticator without revealing the token.
+func (auth *BearerAuth) String() string {
+	return fmt.Sprintf("BearerAuth{Token: %s, Refresh: %t}", redacted, auth.refresh != nil)
+}
+
+// GoString describes the authenticator without revealing the token.
+func (auth *BearerAuth) GoString() string {
+	return auth.String()
+}
+
+// authenticate adds the client's static headers and credentials to a
+// request.
+func (endpoint *SPARQLClient) authenticate(req *http.Request) error {
+	for name, values := range endpoint.Headers {
+		req.Header[http.CanonicalHeaderKey(name)] = append([]string(nil), values...)
+	}
+	if endpoint.Auth == nil {
+		return nil
+	}
+	return endpoint.Auth.Authenticate(req)
+}
+
+// refreshAuth renews the client's credentials after a request has been
+// rejected, reporting whether the request should be tried again.
+func (endpoint *SPARQLClient) refreshAuth(ctx context.Context, resp *http.Response) (bool, error) {
+	if resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		return false, nil
+	}
+	refresher, ok := endpoint.Auth.(Refresher)
+	if !ok {
+		return false, nil
+	}
+	return refresher.Refresh(ctx)
+}
+
+// String describes the client for debugging. Credentials and the
+// values of static headers are redacted.
+func (endpoint SPARQLClient) String() string {
+	headers := make([]string, 0, len(endpoint.Headers))
+	for name := range endpoint.Headers {
+		headers = append(headers, http.CanonicalHeaderKey(name)+": "+redacted)
+	}
+	sort.Strings(headers)
+	var auth string
+	switch authenticator := endpoint.Auth.(type) {
+	case nil:
+		auth = "<nil>"
+	case fmt.Stringer:
+		auth = authenticator.String()
+	default:
+		auth = fmt.Sprintf("%T{%s}", authenticator, redacted)
+	}
+	return fmt.Sprintf("SPARQLClient{BaseURL: %s, UpdateURL: %s, Agent: %s, Accept: %s, Method: %s, Auth: %s, Headers: {%s}}",
+		redactURL(endpoint.BaseURL), redactURL(endpoint.UpdateURL), endpoint.Agent, endpoint.Accept,
+		endpoint.Method, auth, strings.Join(headers, ", "))
+}
+
+// redactURL hides any password included in a URL.
+func redactURL(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.User == nil {
+		return rawURL
+	}
+	if _, ok := parsed.User.Password(); !ok {
+		return rawURL
+	}
+	parsed.User = url.UserPassword(parsed.User.Username(), "xxxxx")
+	return parsed.String()
+}
+
+// GoString describes the client for debugging with %#v, redacting
+// credentials as String does.
+func (endpoint SPARQLClient) GoString() string {
+	return endpoint.String()
+}
@@ -0,0 +1,115 @@
+package spargo
+
+import (
+	"context"
+	"sync"
+)
+
+// DefaultBatchConcurrency is the number of queries SPARQLBatch runs at
+// once if BatchOptions.Concurrency is zero.
+const DefaultBatchConcurrency int = 4
+
+// BatchJob is a single query run by SPARQLBatch.
+type BatchJob struct {
+	// Name optionally identifies the job to the caller, e.g. the file
+	// the query was read from.
+	Name string
+	// Endpoint is the URL the query is sent to. The client's BaseURL
+	// is used if it is empty.
+	Endpoint string
+	// Query is the SPARQL query to run.
+	Query string
+}
+
+// BatchResult is the outcome of a BatchJob.
+type BatchResult struct {
+	Job    BatchJob
+	Result SPARQLResult
+	Err    error
+}
+
+// BatchOptions control how SPARQLBatch runs its jobs.
+type BatchOptions struct {
+	// Concurrency is the most queries run at once.
+	// DefaultBatchConcurrency is used if it is zero.
+	Concurrency int
+	// FailFast abandons the remaining jobs once one has failed.
+	// Otherwise every job is run and its error collected.
+	FailFast bool
+	// QueryOptions are given with every query, e.g. WithCachePolicy.
+	QueryOptions []QueryOption
+}
+
+// SPARQLBatch runs independent queries concurrently and returns their
+// results in the same order as jobs. Each job runs on its own copy of
+// the client, so settings such as the user agent, authentication,
+// limiter and cache are shared but the client itself is not modified.
+//
+// The error returned is the first job to fail when FailFast is set,
+// and jobs abandoned as a result report an error wrapping
+// context.Canceled. Otherwise it is only non-nil if ctx ends before
+// every job has finished. Errors for individual jobs are always
+// available from their BatchResult.
+func (endpoint *SPARQLClient) SPARQLBatch(ctx context.Context, jobs []BatchJob, options BatchOptions) ([]BatchResult, error) {
+	concurrency := options.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultBatchConcurrency
+	}
+	if concurrency > len(jobs) {
+		concurrency = len(jobs)
+	}
+
+	batchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	queue := make(chan int, len(jobs))
+	for index := range jobs {
+		queue <- index
+	}
+	close(queue)
+
+	results := make([]BatchResult, len(jobs))
+	var firstErr error
+	var failOnce sync.Once
+	var wg sync.WaitGroup
+	for worker := 0; worker < concurrency; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range queue {
+				results[index] = endpoint.runJob(batchCtx, jobs[index], options.QueryOptions)
+				if err := results[index].Err; err != nil && options.FailFast {
+					failOnce.Do(func() {
+						firstErr = err
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return results, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return results, contextError(ctx, err)
+	}
+	return results, nil
+}
+
+// runJob runs a single job on a copy of the client.
+func (endpoint *SPARQLClient) runJob(ctx context.Context, job BatchJob, opts []QueryOption) BatchResult {
+	result := BatchResult{Job: job}
+	if err := ctx.Err(); err != nil {
+		result.Err = contextError(ctx, err)
+		return result
+	}
+	client := *endpoint
+	if job.Endpoint != "" {
+		client.BaseURL = job.Endpoint
+	}
+	client.Query = job.Query
+	result.Result, result.Err = client.SPARQLGoContext(ctx, opts...)
+	return result
+}
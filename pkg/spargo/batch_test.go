@@ -0,0 +1,157 @@
+package spargo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newBatchClient returns a test client that answers each query with a
+// single binding holding the query and endpoint, or fails queries of
+// "FAIL". The most requests seen at once is recorded in maxInFlight.
+func newBatchClient(maxInFlight *int) *http.Client {
+	var mutex sync.Mutex
+	inFlight := 0
+	return NewTestClient(func(req *http.Request) *http.Response {
+		mutex.Lock()
+		inFlight++
+		if inFlight > *maxInFlight {
+			*maxInFlight = inFlight
+		}
+		mutex.Unlock()
+		time.Sleep(5 * time.Millisecond)
+		mutex.Lock()
+		inFlight--
+		mutex.Unlock()
+
+		query := req.URL.Query().Get("query")
+		if query == "FAIL" {
+			return &http.Response{
+				StatusCode: 500,
+				Body:       ioutil.NopCloser(bytes.NewBufferString("")),
+				Header:     make(http.Header),
+			}
+		}
+		endpoint := *req.URL
+		endpoint.RawQuery = ""
+		res := SPARQLResult{Results: Binding{Bindings: []map[string]Item{{
+			"query":    {Type: "literal", Value: query},
+			"endpoint": {Type: "uri", Value: endpoint.String()},
+		}}}}
+		body, _ := json.Marshal(res)
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(bytes.NewBuffer(body)),
+			Header:     make(http.Header),
+		}
+	})
+}
+
+var testJobs = []BatchJob{
+	{Name: "pronom", Query: "SELECT 1"},
+	{Name: "wikidata", Endpoint: "http://example.com/wikidata", Query: "SELECT 2"},
+	{Name: "broken", Query: "FAIL"},
+	{Name: "fdd", Query: "SELECT 3"},
+	{Name: "loc", Query: "SELECT 4"},
+	{Name: "other", Query: "SELECT 5"},
+}
+
+// TestBatchCollectAll makes sure every job is run, with bounded
+// concurrency, and results are returned in input order.
+func TestBatchCollectAll(t *testing.T) {
+	maxInFlight := 0
+	sparql := SPARQLClient{}
+	sparql.ClientInit("http://example.com/sparql", "SELECT 0")
+	sparql.Client = newBatchClient(&maxInFlight)
+
+	results, err := sparql.SPARQLBatch(context.Background(), testJobs, BatchOptions{Concurrency: 2})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(results) != len(testJobs) {
+		t.Fatalf("Expected %d results, received %d", len(testJobs), len(results))
+	}
+	for index, result := range results {
+		job := testJobs[index]
+		if result.Job.Name != job.Name {
+			t.Errorf("Result %d is for job '%s', expected '%s'", index, result.Job.Name, job.Name)
+		}
+		if job.Query == "FAIL" {
+			if !errors.As(result.Err, &ResponseError{}) {
+				t.Errorf("Expected a ResponseError for job '%s', received: %v", job.Name, result.Err)
+			}
+			continue
+		}
+		if result.Err != nil {
+			t.Errorf("Unexpected error for job '%s': %s", job.Name, result.Err)
+			continue
+		}
+		binding := result.Result.Results.Bindings[0]
+		endpoint := job.Endpoint
+		if endpoint == "" {
+			endpoint = sparql.BaseURL
+		}
+		if binding["query"].Value != job.Query || binding["endpoint"].Value != endpoint {
+			t.Errorf("Unexpected result for job '%s': %v", job.Name, binding)
+		}
+	}
+	if maxInFlight > 2 {
+		t.Errorf("Expected at most 2 queries at once, received %d", maxInFlight)
+	}
+	if sparql.Query != "SELECT 0" {
+		t.Errorf("Expected the client's query to be left alone, received: %s", sparql.Query)
+	}
+}
+
+// TestBatchFailFast makes sure the remaining jobs are abandoned once a
+// job fails.
+func TestBatchFailFast(t *testing.T) {
+	maxInFlight := 0
+	sparql := SPARQLClient{}
+	sparql.ClientInit("http://example.com/sparql", "")
+	sparql.Client = newBatchClient(&maxInFlight)
+
+	results, err := sparql.SPARQLBatch(context.Background(), testJobs, BatchOptions{Concurrency: 1, FailFast: true})
+	if !errors.As(err, &ResponseError{}) {
+		t.Fatalf("Expected the ResponseError of the failed job, received: %v", err)
+	}
+	for index, result := range results[:2] {
+		if result.Err != nil {
+			t.Errorf("Unexpected error for job %d: %s", index, result.Err)
+		}
+	}
+	for index, result := range results[3:] {
+		if !errors.Is(result.Err, context.Canceled) {
+			t.Errorf("Expected job %d to be abandoned, received: %v", index+3, result.Err)
+		}
+	}
+}
+
+// TestBatchCancel makes sure the batch stops when its context ends.
+func TestBatchCancel(t *testing.T) {
+	maxInFlight := 0
+	sparql := SPARQLClient{}
+	sparql.ClientInit("http://example.com/sparql", "")
+	sparql.Client = newBatchClient(&maxInFlight)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results, err := sparql.SPARQLBatch(ctx, testJobs, BatchOptions{})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected a context.Canceled error, received: %v", err)
+	}
+	for index, result := range results {
+		if !errors.Is(result.Err, context.Canceled) {
+			t.Errorf("Expected job %d to be abandoned, received: %v", index, result.Err)
+		}
+	}
+	if maxInFlight != 0 {
+		t.Errorf("Expected no requests to be sent")
+	}
+}
@@ -0,0 +1,241 @@
+package spargo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultCacheTTL is how long a response is cached for if the client's
+// CacheTTL is zero.
+const DefaultCacheTTL time.Duration = time.Hour
+
+// CacheEntry is a successful response to a query held in a Cache.
+type CacheEntry struct {
+	Body    []byte      `json:"body"`
+	Header  http.Header `json:"header"`
+	Stored  time.Time   `json:"stored"`
+	Expires time.Time   `json:"expires"`
+}
+
+// Expired reports whether the entry has outlived its TTL at the given
+// time.
+func (entry CacheEntry) Expired(now time.Time) bool {
+	return !now.Before(entry.Expires)
+}
+
+// Cache stores responses to queries. Keys are derived from the
+// endpoint, query, dataset, Accept header and request method of a
+// query, and from the credentials and static headers sent with it, so
+// clients with different credentials can share a cache. Keys are
+// hashes, credentials cannot be read back from them. Get should not
+// return entries that have expired. A Cache must be safe for concurrent
+// use.
+type Cache interface {
+	Get(key string) (CacheEntry, bool)
+	Set(key string, entry CacheEntry) error
+	Delete(key string) error
+}
+
+// CachePolicy describes how a single query uses the client's cache.
+type CachePolicy int
+
+// Cache policies that can be given to a query with WithCachePolicy.
+const (
+	// CacheDefault returns a cached response if there is one, and
+	// caches the response otherwise.
+	CacheDefault CachePolicy = iota
+	// CacheBypass neither reads from nor writes to the cache.
+	CacheBypass
+	// CacheRefresh ignores any cached response but caches the new one.
+	CacheRefresh
+)
+
+// WithCachePolicy tells the client how to use its cache for a single
+// query.
+func WithCachePolicy(policy CachePolicy) QueryOption {
+	return func(options *queryOptions) {
+		options.cachePolicy = policy
+	}
+}
+
+// cacheNow can be replaced to control time when testing.
+var cacheNow = time.Now
+
+// cacheKey identifies a query, the dataset it is evaluated against and
+// the credentials it is sent with to the client's cache.
+func (endpoint *SPARQLClient) cacheKey(queryString string, accept string, dataset Dataset, credentials string) string {
+	params := url.Values{}
+	params.Add("query", queryString)
+	dataset.addTo(params)
+	encoded := params.Encode()
+	method := endpoint.resolveMethod(len(encoded))
+	hash := sha256.New()
+	for _, part := range []string{endpoint.BaseURL, encoded, accept, method.String(), credentials} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// credentials describes the headers the client's static headers and
+// Auth added to, or changed in, a request, given its headers before
+// and after it was authenticated. Headers are listed in a stable order
+// so that responses received with the same credentials share a key.
+func credentials(before http.Header, after http.Header) string {
+	names := make([]string, 0, len(after))
+	for name, values := range after {
+		if !reflect.DeepEqual(before[name], values) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	var credentials strings.Builder
+	for _, name := range names {
+		for _, value := range after[name] {
+			fmt.Fprintf(&credentials, "%s: %s\n", name, value)
+		}
+	}
+	return credentials.String()
+}
+
+// cacheTTL returns how long responses are cached for.
+func (endpoint *SPARQLClient) cacheTTL() time.Duration {
+	if endpoint.CacheTTL > 0 {
+		return endpoint.CacheTTL
+	}
+	return DefaultCacheTTL
+}
+
+// MemoryCache is a Cache that holds responses in memory.
+type MemoryCache struct {
+	mutex   sync.Mutex
+	entries map[string]CacheEntry
+}
+
+// NewMemoryCache returns an empty MemoryCache.
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{entries: map[string]CacheEntry{}}
+}
+
+// Get returns the entry stored under key, unless it has expired.
+func (cache *MemoryCache) Get(key string) (CacheEntry, bool) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	entry, ok := cache.entries[key]
+	if !ok {
+		return CacheEntry{}, false
+	}
+	if entry.Expired(cacheNow()) {
+		delete(cache.entries, key)
+		return CacheEntry{}, false
+	}
+	return entry, true
+}
+
+// Set stores an entry under key.
+func (cache *MemoryCache) Set(key string, entry CacheEntry) error {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	if cache.entries == nil {
+		cache.entries = map[string]CacheEntry{}
+	}
+	cache.entries[key] = entry
+	return nil
+}
+
+// Delete removes the entry stored under key.
+func (cache *MemoryCache) Delete(key string) error {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	delete(cache.entries, key)
+	return nil
+}
+
+// Clear removes every entry from the cache.
+func (cache *MemoryCache) Clear() {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	cache.entries = map[string]CacheEntry{}
+}
+
+// DiskCache is a Cache that holds responses as files in a directory,
+// so that they survive between runs of a program.
+type DiskCache struct {
+	dir string
+}
+
+// NewDiskCache returns a DiskCache storing entries in dir, which is
+// created if it doesn't exist.
+func NewDiskCache(dir string) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &DiskCache{dir: dir}, nil
+}
+
+// path returns the file an entry is stored in.
+func (cache *DiskCache) path(key string) string {
+	return filepath.Join(cache.dir, key+".json")
+}
+
+// Get returns the entry stored under key, unless it has expired or
+// cannot be read.
+func (cache *DiskCache) Get(key string) (CacheEntry, bool) {
+	data, err := ioutil.ReadFile(cache.path(key))
+	if err != nil {
+		return CacheEntry{}, false
+	}
+	var entry CacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return CacheEntry{}, false
+	}
+	if entry.Expired(cacheNow()) {
+		cache.Delete(key)
+		return CacheEntry{}, false
+	}
+	return entry, true
+}
+
+// Set stores an entry under key. The entry is written to a temporary
+// file first so that a partly written entry is never read.
+func (cache *DiskCache) Set(key string, entry CacheEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(cache.dir, key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), cache.path(key))
+}
+
+// Delete removes the entry stored under key.
+func (cache *DiskCache) Delete(key string) error {
+	err := os.Remove(cache.path(key))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,263 @@
+package spargo
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+)
+
+// mockCacheNow fixes the time seen by caches, returning a pointer to it
+// and a function that restores the real clock.
+func mockCacheNow() (*time.Time, func()) {
+	now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	cacheNow = func() time.Time { return now }
+	return &now, func() { cacheNow = time.Now }
+}
+
+// testCache runs a client against a cache, checking hits, expiry and
+// cache policies.
+func testCache(t *testing.T, cache Cache) {
+	now, restore := mockCacheNow()
+	defer restore()
+
+	calls := 0
+	sparql := SPARQLClient{}
+	sparql.ClientInit("http://example.com/sparql", "SELECT * WHERE { ?s ?p ?o }")
+	sparql.Client = newSequenceClient(nil, nil, &calls)
+	sparql.SetCache(cache, time.Minute)
+
+	expect := func(policy CachePolicy, cached bool, expectedCalls int) {
+		t.Helper()
+		res, err := sparql.SPARQLGoContext(context.Background(), WithCachePolicy(policy))
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if res.Meta.Cached != cached {
+			t.Errorf("Expected Cached to be %t", cached)
+		}
+		if cached && !res.Meta.Expires.Equal(res.Meta.Stored.Add(time.Minute)) {
+			t.Errorf("Unexpected expiry: %s", res.Meta.Expires)
+		}
+		if calls != expectedCalls {
+			t.Errorf("Expected %d requests, received %d", expectedCalls, calls)
+		}
+		if len(res.Results.Bindings) == 0 {
+			t.Errorf("Unexpected result: %s", res)
+		}
+	}
+
+	expect(CacheDefault, false, 1)
+	expect(CacheDefault, true, 1)
+	expect(CacheBypass, false, 2)
+	expect(CacheRefresh, false, 3)
+	expect(CacheDefault, true, 3)
+
+	// A different Accept header or method is a different query.
+	sparql.SetAcceptHeader(jsonResultsMediaType)
+	expect(CacheDefault, false, 4)
+	sparql.SetMethod(MethodPostDirect)
+	expect(CacheDefault, false, 5)
+	expect(CacheDefault, true, 5)
+
+	*now = now.Add(time.Minute)
+	expect(CacheDefault, false, 6)
+}
+
+// TestMemoryCache makes sure results are cached in memory.
+func TestMemoryCache(t *testing.T) {
+	testCache(t, NewMemoryCache())
+}
+
+// TestDiskCache makes sure results are cached on disk, and that cached
+// results are still there for a new cache in the same directory.
+func TestDiskCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spargo-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cache, err := NewDiskCache(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCache(t, cache)
+
+	entry := CacheEntry{Body: []byte("body"), Stored: time.Now(), Expires: time.Now().Add(time.Hour)}
+	if err := cache.Set("key", entry); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	reopened, _ := NewDiskCache(dir)
+	if res, ok := reopened.Get("key"); !ok || string(res.Body) != "body" {
+		t.Errorf("Expected the entry to be read back from disk, received: %v", res)
+	}
+	if err := reopened.Delete("key"); err != nil {
+		t.Errorf("Unexpected error deleting entry: %s", err)
+	}
+	if _, ok := cache.Get("key"); ok {
+		t.Errorf("Expected the entry to be deleted")
+	}
+}
+
+// TestCacheErrors makes sure failed queries aren't cached.
+func TestCacheErrors(t *testing.T) {
+	calls := 0
+	sparql := SPARQLClient{}
+	sparql.ClientInit("http://example.com/sparql", "SELECT * WHERE { ?s ?p ?o }")
+	sparql.Client = newSequenceClient([]int{503}, nil, &calls)
+	sparql.SetCache(NewMemoryCache(), 0)
+	if _, err := sparql.SPARQLGo(); err == nil {
+		t.Fatalf("Expected an error")
+	}
+	if res, err := sparql.SPARQLGo(); err != nil || res.Meta.Cached {
+		t.Errorf("Expected an uncached result, received: %v", err)
+	}
+}
+
+// TestCacheMeta makes sure graph and ASK queries report whether their
+// responses came from the cache.
+func TestCacheMeta(t *testing.T) {
+	calls := 0
+	response := testAskTrue
+	contentType := jsonResultsMediaType
+	httpClient := NewTestClient(func(req *http.Request) *http.Response {
+		calls++
+		header := make(http.Header)
+		header.Set("Content-Type", contentType)
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(bytes.NewBufferString(response)),
+			Header:     header,
+		}
+	})
+	ctx := context.Background()
+
+	sparql := SPARQLClient{}
+	sparql.ClientInit("http://example.com/sparql", "ASK { ?s ?p ?o }")
+	sparql.Client = httpClient
+	sparql.SetCache(NewMemoryCache(), 0)
+	for _, cached := range []bool{false, true} {
+		answer, meta, err := sparql.AskMeta(ctx)
+		if err != nil || !answer {
+			t.Fatalf("Unexpected answer %t: %v", answer, err)
+		}
+		if meta.Cached != cached {
+			t.Errorf("Expected Cached to be %t for ASK", cached)
+		}
+	}
+
+	response = testNTriples
+	contentType = "application/n-triples"
+	sparql.SetQuery("DESCRIBE <http://www.wikidata.org/entity/Q931783>")
+	for _, cached := range []bool{false, true} {
+		graph, meta, err := sparql.SPARQLGraphMeta(ctx)
+		if err != nil || len(graph) == 0 {
+			t.Fatalf("Unexpected graph %v: %v", graph, err)
+		}
+		if meta.Cached != cached {
+			t.Errorf("Expected Cached to be %t for DESCRIBE", cached)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("Expected 2 requests, received %d", calls)
+	}
+}
+
+// TestCachePolicyBatch makes sure query options given to a batch are
+// used for each of its jobs.
+func TestCachePolicyBatch(t *testing.T) {
+	calls := 0
+	sparql := SPARQLClient{}
+	sparql.ClientInit("http://example.com/sparql", "SELECT * WHERE { ?s ?p ?o }")
+	sparql.Client = newSequenceClient(nil, nil, &calls)
+	sparql.SetCache(NewMemoryCache(), 0)
+
+	jobs := []BatchJob{{Query: sparql.Query}, {Query: sparql.Query}}
+	options := BatchOptions{Concurrency: 1, QueryOptions: []QueryOption{WithCachePolicy(CacheBypass)}}
+	results, err := sparql.SPARQLBatch(context.Background(), jobs, options)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	for _, result := range results {
+		if result.Err != nil || result.Result.Meta.Cached {
+			t.Errorf("Expected an uncached result, received: %v", result.Err)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("Expected 2 requests, received %d", calls)
+	}
+}
+
+// TestCacheCredentials makes sure clients with different credentials,
+// or static headers, sharing a cache don't receive each other's
+// results.
+func TestCacheCredentials(t *testing.T) {
+	calls := 0
+	cache := NewMemoryCache()
+	newClient := func() *SPARQLClient {
+		sparql := &SPARQLClient{}
+		sparql.ClientInit("http://example.com/sparql", "SELECT * WHERE { ?s ?p ?o }")
+		sparql.Client = newSequenceClient(nil, nil, &calls)
+		sparql.SetCache(cache, 0)
+		return sparql
+	}
+	expect := func(sparql *SPARQLClient, cached bool, expectedCalls int) {
+		t.Helper()
+		res, err := sparql.SPARQLGo()
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if res.Meta.Cached != cached {
+			t.Errorf("Expected Cached to be %t", cached)
+		}
+		if calls != expectedCalls {
+			t.Errorf("Expected %d requests, received %d", expectedCalls, calls)
+		}
+	}
+
+	alice := newClient()
+	alice.SetBasicAuth("alice", "secret")
+	bob := newClient()
+	bob.SetBasicAuth("bob", "secret")
+	expect(alice, false, 1)
+	expect(bob, false, 2)
+	expect(alice, true, 2)
+	expect(bob, true, 2)
+
+	bob.SetBasicAuth("bob", "changed")
+	expect(bob, false, 3)
+	bob.SetHeader("X-Api-Key", "key")
+	expect(bob, false, 4)
+	expect(bob, true, 4)
+}
+
+// TestCacheRefreshedCredentials makes sure a response received after
+// the client's credentials were refreshed is cached under the new
+// credentials, so that it is found again.
+func TestCacheRefreshedCredentials(t *testing.T) {
+	var headers []http.Header
+	sparql := SPARQLClient{}
+	sparql.ClientInit("http://example.com/sparql", "SELECT * WHERE { ?s ?p ?o }")
+	sparql.Client = newAuthClient("Bearer new", &headers)
+	sparql.SetCache(NewMemoryCache(), 0)
+	sparql.SetBearerToken("old", func(ctx context.Context) (string, error) {
+		return "new", nil
+	})
+
+	if res, err := sparql.SPARQLGo(); err != nil || res.Meta.Cached {
+		t.Fatalf("Expected an uncached result, received: %v", err)
+	}
+	if len(headers) != 2 {
+		t.Fatalf("Expected the rejected request to be sent again, %d requests sent", len(headers))
+	}
+	res, err := sparql.SPARQLGo()
+	if err != nil || !res.Meta.Cached {
+		t.Errorf("Expected a cached result, received: %v", err)
+	}
+	if len(headers) != 2 {
+		t.Errorf("Expected no further requests, %d requests sent", len(headers))
+	}
+}
@@ -0,0 +1,66 @@
+package spargo
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestSparqlHandlerCancelled makes sure that a query made with a
+// context that has already been cancelled returns a distinguishable
+// error and not a result, even if the server responds.
+func TestSparqlHandlerCancelled(t *testing.T) {
+	httpClient := NewTestClient(func(req *http.Request) *http.Response {
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(bytes.NewBufferString(testString)),
+			Header:     make(http.Header),
+		}
+	})
+
+	sparql := SPARQLClient{}
+	sparql.Client = httpClient
+	sparql.ClientInit("http://example.com", testQuery)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	response, err := sparql.SPARQLGoContext(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected a context.Canceled error from SPARQLGoContext, received: %v", err)
+	}
+	if !reflect.DeepEqual(response, SPARQLResult{}) {
+		t.Errorf("Expected an empty SPARQL result, received; %s", response.String())
+	}
+}
+
+// TestSparqlHandlerDeadline makes sure that a stuck request is
+// abandoned once the context deadline passes.
+func TestSparqlHandlerDeadline(t *testing.T) {
+	httpClient := &http.Client{
+		Transport: RoundTripFuncError(func(req *http.Request) *http.Response {
+			<-req.Context().Done()
+			return nil
+		}),
+	}
+
+	sparql := SPARQLClient{}
+	sparql.Client = httpClient
+	sparql.ClientInit("http://example.com", testQuery)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	response, err := sparql.SPARQLGoContext(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected a context.DeadlineExceeded error from SPARQLGoContext, received: %v", err)
+	}
+	if !reflect.DeepEqual(response, SPARQLResult{}) {
+		t.Errorf("Expected an empty SPARQL result, received; %s", response.String())
+	}
+}
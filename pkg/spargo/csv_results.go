@@ -0,0 +1,136 @@
+package spargo
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+)
+
+// DecodeCSVResults reads SPARQL results in the SPARQL 1.1 Query Results
+// CSV Format. CSV does not record the type of each value, so it is
+// inferred: values starting "_:" are blank nodes, absolute IRIs are
+// treated as IRIs, and anything else as a plain literal. Language tags
+// and datatypes are lost. TSV should be preferred where that matters.
+func DecodeCSVResults(reader io.Reader) (SPARQLResult, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+	records, err := csvReader.ReadAll()
+	if err != nil {
+		return SPARQLResult{}, err
+	}
+	if len(records) == 0 {
+		return SPARQLResult{}, fmt.Errorf("spargo: csv results have no header")
+	}
+
+	vars := records[0]
+	sparqlResponse := newTabularResult(vars)
+	for line, record := range records[1:] {
+		if len(record) != len(vars) {
+			return SPARQLResult{}, fmt.Errorf("spargo: csv results row %d has %d values, expected %d", line+1, len(record), len(vars))
+		}
+		row := make(map[string]Item)
+		for i, value := range record {
+			if value == "" {
+				continue
+			}
+			row[vars[i]] = inferCSVItem(value)
+		}
+		sparqlResponse.Results.Bindings = append(sparqlResponse.Results.Bindings, row)
+	}
+	return sparqlResponse, nil
+}
+
+// inferCSVItem guesses the type of a value from CSV results.
+func inferCSVItem(value string) Item {
+	if strings.HasPrefix(value, blankNodeLabel) {
+		return BlankNode(strings.TrimPrefix(value, blankNodeLabel)).Item()
+	}
+	if parsed, err := url.Parse(value); err == nil && parsed.Scheme != "" && (parsed.Host != "" || parsed.Opaque != "") && !strings.ContainsAny(value, " \t\n") {
+		return IRI(value).Item()
+	}
+	return Literal{Value: value}.Item()
+}
+
+// DecodeTSVResults reads SPARQL results in the SPARQL 1.1 Query Results
+// TSV Format. Values are written using RDF term syntax, e.g. <iri>,
+// "label"@en or "5"^^<http://www.w3.org/2001/XMLSchema#int>, and are
+// parsed back into the type, language and datatype of each Item. The
+// xsd: and rdf: prefixes are understood in datatypes.
+func DecodeTSVResults(reader io.Reader) (SPARQLResult, error) {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return SPARQLResult{}, err
+		}
+		return SPARQLResult{}, fmt.Errorf("spargo: tsv results have no header")
+	}
+	var vars []string
+	for _, variable := range strings.Split(strings.TrimRight(scanner.Text(), "\r"), "\t") {
+		vars = append(vars, strings.TrimLeft(variable, "?$"))
+	}
+
+	sparqlResponse := newTabularResult(vars)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if text == "" && len(vars) > 1 {
+			continue
+		}
+		values := strings.Split(text, "\t")
+		if len(values) != len(vars) {
+			return SPARQLResult{}, fmt.Errorf("spargo: tsv results row %d has %d values, expected %d", line, len(values), len(vars))
+		}
+		row := make(map[string]Item)
+		for i, value := range values {
+			if value == "" {
+				continue
+			}
+			term, err := parseTerm(value)
+			if err != nil {
+				return SPARQLResult{}, fmt.Errorf("spargo: tsv results row %d: %w", line, err)
+			}
+			row[vars[i]] = term.Item()
+		}
+		sparqlResponse.Results.Bindings = append(sparqlResponse.Results.Bindings, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return SPARQLResult{}, err
+	}
+	return sparqlResponse, nil
+}
+
+// newTabularResult creates an empty result for the given variables.
+func newTabularResult(vars []string) SPARQLResult {
+	head := make([]interface{}, 0, len(vars))
+	for _, variable := range vars {
+		head = append(head, variable)
+	}
+	return SPARQLResult{
+		Head:    map[string]interface{}{"vars": head},
+		Results: Binding{Bindings: []map[string]Item{}},
+	}
+}
+
+// parseTerm parses a single RDF term written in Turtle syntax.
+func parseTerm(value string) (Term, error) {
+	parser := turtleParser{
+		input: []rune(value),
+		prefixes: map[string]string{
+			"xsd": xsdNamespace,
+			"rdf": rdfNamespace,
+		},
+	}
+	term, err := parser.object()
+	if err != nil {
+		return nil, err
+	}
+	parser.skipSpace()
+	if !parser.eof() {
+		return nil, parser.errorf("unexpected %s after term", parser.describeNext())
+	}
+	return term, nil
+}
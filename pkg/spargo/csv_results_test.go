@@ -0,0 +1,80 @@
+package spargo
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var testCSV = "format,label,note\r\n" +
+	"http://the-fr.org/id/file-format/25,OS/2 Bitmap,\r\n" +
+	"_:b0,\"CALS, Compressed Bitmap\",urn:isbn:0451450523\r\n"
+
+// TestDecodeCSVResults makes sure values in CSV results are decoded and
+// their types inferred.
+func TestDecodeCSVResults(t *testing.T) {
+	res, err := DecodeCSVResults(strings.NewReader(testCSV))
+	if err != nil {
+		t.Fatalf("Unexpected error decoding CSV results: %s", err)
+	}
+	expected := []map[string]Item{
+		{
+			"format": Item{Type: "uri", Value: "http://the-fr.org/id/file-format/25"},
+			"label":  Item{Type: "literal", Value: "OS/2 Bitmap"},
+		},
+		{
+			"format": Item{Type: "bnode", Value: "b0"},
+			"label":  Item{Type: "literal", Value: "CALS, Compressed Bitmap"},
+			"note":   Item{Type: "uri", Value: "urn:isbn:0451450523"},
+		},
+	}
+	if !reflect.DeepEqual(res.Results.Bindings, expected) {
+		t.Errorf("Unexpected CSV bindings: %+v", res.Results.Bindings)
+	}
+	if !reflect.DeepEqual(res.Head["vars"], []interface{}{"format", "label", "note"}) {
+		t.Errorf("Unexpected CSV vars: %v", res.Head["vars"])
+	}
+}
+
+var testTSV = "?format\t?label\t?count\t?flag\n" +
+	"<http://the-fr.org/id/file-format/25>\t\"OS/2\\tBitmap\"@en-GB\t\"5\"^^xsd:int\ttrue\n" +
+	"_:b0\t\"CALS \\\"Compressed\\\" Bitmap\"^^<http://www.w3.org/2001/XMLSchema#string>\t1.5e2\t\n"
+
+// TestDecodeTSVResults makes sure RDF term syntax in TSV results is
+// parsed into the type, language and datatype of each Item.
+func TestDecodeTSVResults(t *testing.T) {
+	res, err := DecodeTSVResults(strings.NewReader(testTSV))
+	if err != nil {
+		t.Fatalf("Unexpected error decoding TSV results: %s", err)
+	}
+	expected := []map[string]Item{
+		{
+			"format": Item{Type: "uri", Value: "http://the-fr.org/id/file-format/25"},
+			"label":  Item{Type: "literal", Value: "OS/2\tBitmap", Lang: "en-GB"},
+			"count":  Item{Type: "literal", Value: "5", DataType: xsdNamespace + "int"},
+			"flag":   Item{Type: "literal", Value: "true", DataType: XSDBoolean},
+		},
+		{
+			"format": Item{Type: "bnode", Value: "b0"},
+			"label":  Item{Type: "literal", Value: `CALS "Compressed" Bitmap`},
+			"count":  Item{Type: "literal", Value: "1.5e2", DataType: XSDDouble},
+		},
+	}
+	if !reflect.DeepEqual(res.Results.Bindings, expected) {
+		t.Errorf("Unexpected TSV bindings: %+v", res.Results.Bindings)
+	}
+	if !reflect.DeepEqual(res.Head["vars"], []interface{}{"format", "label", "count", "flag"}) {
+		t.Errorf("Unexpected TSV vars: %v", res.Head["vars"])
+	}
+
+	invalid := []string{
+		"?a\t?b\n<http://example.com>\n",
+		"?a\n<http://example.com> trailing\n",
+		"?a\n\"unterminated\n",
+	}
+	for _, tsv := range invalid {
+		if _, err := DecodeTSVResults(strings.NewReader(tsv)); err == nil {
+			t.Errorf("Expected an error decoding invalid TSV: %q", tsv)
+		}
+	}
+}
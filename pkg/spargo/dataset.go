@@ -0,0 +1,61 @@
+package spargo
+
+import (
+	"net/url"
+)
+
+// Dataset describes the RDF dataset a query is evaluated against, as
+// sent using the default-graph-uri and named-graph-uri parameters of
+// the SPARQL 1.1 Protocol. A dataset sent with a query overrides any
+// FROM and FROM NAMED clauses in it.
+type Dataset struct {
+	// DefaultGraphURIs are merged to make the default graph.
+	DefaultGraphURIs []string
+	// NamedGraphURIs are the named graphs available to GRAPH.
+	NamedGraphURIs []string
+}
+
+// IsEmpty reports whether the dataset names no graphs, in which case
+// the endpoint's own dataset is used.
+func (dataset Dataset) IsEmpty() bool {
+	return len(dataset.DefaultGraphURIs) == 0 && len(dataset.NamedGraphURIs) == 0
+}
+
+// validate makes sure every graph in the dataset is an absolute IRI.
+func (dataset Dataset) validate() error {
+	for _, graphs := range [][]string{dataset.DefaultGraphURIs, dataset.NamedGraphURIs} {
+		for _, graph := range graphs {
+			if err := ValidateIRI(graph); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// addTo adds the dataset's parameters to a set of request parameters.
+func (dataset Dataset) addTo(params url.Values) {
+	for _, graph := range dataset.DefaultGraphURIs {
+		params.Add("default-graph-uri", graph)
+	}
+	for _, graph := range dataset.NamedGraphURIs {
+		params.Add("named-graph-uri", graph)
+	}
+}
+
+// WithDataset tells the client to query the given dataset, in place of
+// the client's own Dataset, for a single query. An empty dataset sends
+// no graphs, so that the endpoint's own dataset is used.
+func WithDataset(dataset Dataset) QueryOption {
+	return func(options *queryOptions) {
+		options.dataset = &dataset
+	}
+}
+
+// dataset returns the dataset to send with a query made with options.
+func (endpoint *SPARQLClient) dataset(options queryOptions) Dataset {
+	if options.dataset != nil {
+		return *options.dataset
+	}
+	return endpoint.Dataset
+}
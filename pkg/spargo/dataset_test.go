@@ -0,0 +1,93 @@
+package spargo
+
+import (
+	"context"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+var testDataset = Dataset{
+	DefaultGraphURIs: []string{"http://example.com/graph/pronom", "http://example.com/graph/fdd"},
+	NamedGraphURIs:   []string{"http://example.com/graph/wikidata"},
+}
+
+// TestDatasetMethods makes sure the dataset is sent in the URL or the
+// form body as appropriate for each request method.
+func TestDatasetMethods(t *testing.T) {
+	for _, method := range []RequestMethod{MethodGet, MethodPostForm, MethodPostDirect} {
+		captured := capturedRequest{}
+		sparql := SPARQLClient{}
+		sparql.Client = newCapturingClient(&captured, 200)
+		sparql.ClientInit("http://example.com/sparql?format=json", testQuery)
+		sparql.SetMethod(method)
+		sparql.SetDataset(testDataset.DefaultGraphURIs, testDataset.NamedGraphURIs)
+
+		if _, err := sparql.SPARQLGo(); err != nil {
+			t.Errorf("Unexpected error for method %s: %s", method, err)
+			continue
+		}
+		params := captured.url.Query()
+		if method == MethodPostForm {
+			form, err := url.ParseQuery(captured.body)
+			if err != nil {
+				t.Errorf("Cannot parse form body: %s", err)
+			}
+			if len(params["default-graph-uri"]) != 0 {
+				t.Errorf("Expected the dataset in the form body only: %s", captured.url)
+			}
+			params = form
+		}
+		if !reflect.DeepEqual(params["default-graph-uri"], testDataset.DefaultGraphURIs) {
+			t.Errorf("Unexpected default graphs for %s: %v", method, params["default-graph-uri"])
+		}
+		if !reflect.DeepEqual(params["named-graph-uri"], testDataset.NamedGraphURIs) {
+			t.Errorf("Unexpected named graphs for %s: %v", method, params["named-graph-uri"])
+		}
+		if captured.url.Query().Get("format") != "json" {
+			t.Errorf("Parameters in the endpoint URL should be preserved for %s: %s", method, captured.url)
+		}
+	}
+}
+
+// TestDatasetPerQuery makes sure a dataset given with a query replaces
+// the client's dataset, and that no dataset is sent by default.
+func TestDatasetPerQuery(t *testing.T) {
+	captured := capturedRequest{}
+	sparql := SPARQLClient{}
+	sparql.Client = newCapturingClient(&captured, 200)
+	sparql.ClientInit("http://example.com/sparql", testQuery)
+
+	if _, err := sparql.SPARQLGo(); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	params := captured.url.Query()
+	if _, ok := params["default-graph-uri"]; ok {
+		t.Errorf("Expected no dataset to be sent: %s", captured.url)
+	}
+
+	sparql.SetDataset(testDataset.DefaultGraphURIs, nil)
+	ctx := context.Background()
+	other := Dataset{NamedGraphURIs: []string{"http://example.com/graph/other"}}
+	if _, err := sparql.SPARQLGoContext(ctx, WithDataset(other)); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	params = captured.url.Query()
+	if len(params["default-graph-uri"]) != 0 || params.Get("named-graph-uri") != "http://example.com/graph/other" {
+		t.Errorf("Expected the per-query dataset to be sent: %s", captured.url)
+	}
+
+	// An empty dataset overrides the client's, so no graphs are sent.
+	if _, err := sparql.SPARQLGoContext(ctx, WithDataset(Dataset{})); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	params = captured.url.Query()
+	if len(params["default-graph-uri"]) != 0 || len(params["named-graph-uri"]) != 0 {
+		t.Errorf("Expected no dataset to be sent: %s", captured.url)
+	}
+
+	invalid := Dataset{DefaultGraphURIs: []string{"not absolute"}}
+	if _, err := sparql.SPARQLGoContext(ctx, WithDataset(invalid)); err == nil {
+		t.Errorf("Expected an error for an invalid graph IRI")
+	}
+}
@@ -0,0 +1,157 @@
+package spargo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Media types for SPARQL results and RDF graph serializations.
+const (
+	jsonResultsMediaType string = "application/sparql-results+json"
+	xmlResultsMediaType  string = "application/sparql-results+xml"
+	csvResultsMediaType  string = "text/csv"
+	tsvResultsMediaType  string = "text/tab-separated-values"
+
+	nTriplesMediaType string = "application/n-triples"
+	turtleMediaType   string = "text/turtle"
+)
+
+// mediaType returns the media type of a response, without parameters
+// such as charset, or an empty string if none was given.
+func mediaType(header http.Header) string {
+	contentType := header.Get("Content-Type")
+	if contentType == "" {
+		return ""
+	}
+	media, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(contentType))
+	}
+	return media
+}
+
+// DecodeJSONResults reads SPARQL results in the SPARQL 1.1 Query Results
+// JSON Format.
+func DecodeJSONResults(reader io.Reader) (SPARQLResult, error) {
+	body, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return SPARQLResult{}, err
+	}
+	var sparqlResponse SPARQLResult
+	if err := json.Unmarshal(body, &sparqlResponse); err != nil {
+		return SPARQLResult{}, err
+	}
+	return sparqlResponse, nil
+}
+
+// ResultsDecoder reads the response to a SELECT or ASK query.
+type ResultsDecoder func(reader io.Reader) (SPARQLResult, error)
+
+// GraphDecoder reads the response to a CONSTRUCT or DESCRIBE query.
+type GraphDecoder func(reader io.Reader) (Graph, error)
+
+// UnsupportedMediaTypeError is returned when there is no decoder for
+// the content type of a response, e.g. an HTML error page returned by
+// a proxy in front of the endpoint.
+type UnsupportedMediaTypeError struct {
+	// MediaType is the content type of the response without
+	// parameters.
+	MediaType string
+	// Graph is true if a graph was expected rather than results.
+	Graph bool
+}
+
+// Error enables UnsupportedMediaTypeError to implement the Errors
+// interface.
+func (err UnsupportedMediaTypeError) Error() string {
+	kind := "results"
+	if err.Graph {
+		kind = "graph"
+	}
+	return fmt.Sprintf("spargo: unsupported %s content type: '%s'", kind, err.MediaType)
+}
+
+// Decoders for each media type. A response without a content type is
+// decoded as JSON results, or a Turtle graph, as these are what we ask
+// for by default.
+var (
+	decoderMutex    sync.RWMutex
+	resultsDecoders = map[string]ResultsDecoder{
+		"":                   DecodeJSONResults,
+		jsonResultsMediaType: DecodeJSONResults,
+		"application/json":   DecodeJSONResults,
+		xmlResultsMediaType:  DecodeXMLResults,
+		"application/xml":    DecodeXMLResults,
+		"text/xml":           DecodeXMLResults,
+		csvResultsMediaType:  DecodeCSVResults,
+		tsvResultsMediaType:  DecodeTSVResults,
+	}
+	graphDecoders = map[string]GraphDecoder{
+		"":                     ParseTurtle,
+		nTriplesMediaType:      ParseNTriples,
+		"text/plain":           ParseNTriples,
+		turtleMediaType:        ParseTurtle,
+		"application/x-turtle": ParseTurtle,
+	}
+)
+
+// RegisterResultsDecoder sets the decoder used for results with the
+// given media type, replacing any existing decoder. Passing a nil
+// decoder removes support for the media type.
+func RegisterResultsDecoder(media string, decoder ResultsDecoder) {
+	decoderMutex.Lock()
+	defer decoderMutex.Unlock()
+	media = strings.ToLower(media)
+	if decoder == nil {
+		delete(resultsDecoders, media)
+		return
+	}
+	resultsDecoders[media] = decoder
+}
+
+// RegisterGraphDecoder sets the decoder used for graphs with the given
+// media type, replacing any existing decoder. Passing a nil decoder
+// removes support for the media type.
+func RegisterGraphDecoder(media string, decoder GraphDecoder) {
+	decoderMutex.Lock()
+	defer decoderMutex.Unlock()
+	media = strings.ToLower(media)
+	if decoder == nil {
+		delete(graphDecoders, media)
+		return
+	}
+	graphDecoders[media] = decoder
+}
+
+// decodeResults parses the body of a response to a SELECT or ASK query
+// using the decoder registered for its content type.
+func decodeResults(header http.Header, body []byte) (SPARQLResult, error) {
+	media := mediaType(header)
+	decoderMutex.RLock()
+	decoder, ok := resultsDecoders[media]
+	decoderMutex.RUnlock()
+	if !ok {
+		return SPARQLResult{}, UnsupportedMediaTypeError{MediaType: media}
+	}
+	return decoder(bytes.NewReader(body))
+}
+
+// decodeGraph parses the body of a response to a CONSTRUCT or DESCRIBE
+// query using the decoder registered for its content type.
+func decodeGraph(header http.Header, body []byte) (Graph, error) {
+	media := mediaType(header)
+	decoderMutex.RLock()
+	decoder, ok := graphDecoders[media]
+	decoderMutex.RUnlock()
+	if !ok {
+		return nil, UnsupportedMediaTypeError{MediaType: media, Graph: true}
+	}
+	return decoder(bytes.NewReader(body))
+}
@@ -0,0 +1,76 @@
+package spargo
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+// TestUnsupportedMediaType makes sure a response we have no decoder for
+// is reported using UnsupportedMediaTypeError, and isn't cached.
+func TestUnsupportedMediaType(t *testing.T) {
+	sparql := SPARQLClient{}
+	sparql.ClientInit("http://example.com/sparql", "SELECT * WHERE { ?s ?p ?o }")
+	sparql.Client = newErrorClient(200, "text/html; charset=utf-8", testHTMLError)
+	cache := NewMemoryCache()
+	sparql.SetCache(cache, 0)
+
+	_, err := sparql.SPARQLGo()
+	mediaErr := UnsupportedMediaTypeError{}
+	if !errors.As(err, &mediaErr) || mediaErr.MediaType != "text/html" || mediaErr.Graph {
+		t.Errorf("Expected an UnsupportedMediaTypeError for results, received: %v", err)
+	}
+	if len(cache.entries) != 0 {
+		t.Errorf("Expected the response not to be cached")
+	}
+	_, err = sparql.SPARQLGraph()
+	if !errors.As(err, &mediaErr) || !mediaErr.Graph {
+		t.Errorf("Expected an UnsupportedMediaTypeError for a graph, received: %v", err)
+	}
+}
+
+// TestRegisterDecoder makes sure decoders can be added for other media
+// types.
+func TestRegisterDecoder(t *testing.T) {
+	const media = "application/x-lines"
+	RegisterResultsDecoder(media, func(reader io.Reader) (SPARQLResult, error) {
+		body, err := ioutil.ReadAll(reader)
+		if err != nil {
+			return SPARQLResult{}, err
+		}
+		res := SPARQLResult{Head: map[string]interface{}{"vars": []interface{}{"line"}}}
+		for _, line := range strings.Split(strings.TrimSpace(string(body)), "\n") {
+			res.Results.Bindings = append(res.Results.Bindings, map[string]Item{
+				"line": {Type: "literal", Value: line},
+			})
+		}
+		return res, nil
+	})
+	RegisterGraphDecoder(strings.ToUpper(media), func(reader io.Reader) (Graph, error) {
+		return Graph{{Subject: IRI("http://example.com/s"), Predicate: IRI("http://example.com/p"), Object: Literal{Value: "o"}}}, nil
+	})
+	defer RegisterResultsDecoder(media, nil)
+	defer RegisterGraphDecoder(media, nil)
+
+	sparql := SPARQLClient{}
+	sparql.ClientInit("http://example.com/sparql", "SELECT * WHERE { ?s ?p ?o }")
+	sparql.Client = newErrorClient(200, media, "one\ntwo\n")
+	res, err := sparql.SPARQLGo()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(res.Results.Bindings) != 2 || res.Results.Bindings[1]["line"].Value != "two" {
+		t.Errorf("Unexpected result: %s", res)
+	}
+	graph, err := sparql.SPARQLGraph()
+	if err != nil || len(graph) != 1 {
+		t.Errorf("Unexpected graph: %v %v", graph, err)
+	}
+
+	RegisterResultsDecoder(media, nil)
+	if _, err := sparql.SPARQLGo(); !errors.As(err, &UnsupportedMediaTypeError{}) {
+		t.Errorf("Expected the decoder to be removed, received: %v", err)
+	}
+}
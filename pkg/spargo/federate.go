@@ -0,0 +1,130 @@
+package spargo
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DefaultSourceVariable is the variable SPARQLFederate binds to the
+// endpoint each row came from if FederationOptions.SourceVariable is
+// empty.
+const DefaultSourceVariable string = "source"
+
+// FederationOptions control how SPARQLFederate runs and merges a query.
+type FederationOptions struct {
+	// Concurrency is the most endpoints queried at once.
+	// DefaultBatchConcurrency is used if it is zero.
+	Concurrency int
+	// SourceVariable is bound to the URL of the endpoint each row came
+	// from. DefaultSourceVariable is used if it is empty.
+	SourceVariable string
+	// Deduplicate drops rows whose bindings, other than the source,
+	// have already been returned by an endpoint earlier in the list.
+	Deduplicate bool
+	// FailFast abandons the query on every endpoint once one fails.
+	// Otherwise the results of the endpoints that succeeded are
+	// returned alongside a FederationError.
+	FailFast bool
+	// QueryOptions are given with the query sent to every endpoint.
+	QueryOptions []QueryOption
+}
+
+// FederationError reports the endpoints a federated query failed on.
+type FederationError struct {
+	Failures []BatchResult
+}
+
+// Error enables FederationError to implement the Errors interface.
+func (err FederationError) Error() string {
+	failures := make([]string, len(err.Failures))
+	for index, failure := range err.Failures {
+		failures[index] = fmt.Sprintf("%s: %s", redactURL(failure.Job.Endpoint), failure.Err)
+	}
+	return fmt.Sprintf("spargo: federated query failed on %d endpoint(s): %s",
+		len(err.Failures), strings.Join(failures, "; "))
+}
+
+// SPARQLFederate runs the client's SELECT query against each of the
+// endpoints concurrently and returns the union of their bindings. Each
+// row is tagged with the endpoint it came from, as an IRI bound to the
+// source variable, which is added to the variables in the head of the
+// result. Any password in an endpoint's URL is redacted from the tag.
+// Rows appear in the order of the endpoints given.
+func (endpoint *SPARQLClient) SPARQLFederate(ctx context.Context, endpoints []string, options FederationOptions) (SPARQLResult, error) {
+	source := options.SourceVariable
+	if source == "" {
+		source = DefaultSourceVariable
+	}
+	jobs := make([]BatchJob, len(endpoints))
+	for index, address := range endpoints {
+		jobs[index] = BatchJob{Name: address, Endpoint: address, Query: endpoint.Query}
+	}
+	batchOptions := BatchOptions{
+		Concurrency:  options.Concurrency,
+		FailFast:     options.FailFast,
+		QueryOptions: options.QueryOptions,
+	}
+	results, err := endpoint.SPARQLBatch(ctx, jobs, batchOptions)
+	if err != nil && (options.FailFast || ctx.Err() != nil) {
+		return SPARQLResult{}, err
+	}
+
+	var vars []interface{}
+	seenVars := map[string]bool{}
+	seenRows := map[string]bool{}
+	merged := SPARQLResult{Results: Binding{Bindings: []map[string]Item{}}}
+	var failures []BatchResult
+	for _, result := range results {
+		if result.Err != nil {
+			failures = append(failures, result)
+			continue
+		}
+		for _, name := range headVars(result.Result.Head) {
+			if name == source {
+				return SPARQLResult{}, fmt.Errorf("spargo: source variable '%s' is already used by the query", source)
+			}
+			if !seenVars[name] {
+				seenVars[name] = true
+				vars = append(vars, name)
+			}
+		}
+		for _, binding := range result.Result.Results.Bindings {
+			if options.Deduplicate {
+				key := bindingKey(binding)
+				if seenRows[key] {
+					continue
+				}
+				seenRows[key] = true
+			}
+			row := make(map[string]Item, len(binding)+1)
+			for name, item := range binding {
+				row[name] = item
+			}
+			row[source] = Item{Type: "uri", Value: redactURL(result.Job.Endpoint)}
+			merged.Results.Bindings = append(merged.Results.Bindings, row)
+		}
+	}
+	merged.Head = map[string]interface{}{"vars": append(vars, source)}
+	if len(failures) > 0 {
+		return merged, FederationError{Failures: failures}
+	}
+	return merged, nil
+}
+
+// bindingKey identifies the values in a binding so that duplicate rows
+// can be found.
+func bindingKey(binding map[string]Item) string {
+	names := make([]string, 0, len(binding))
+	for name := range binding {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var key strings.Builder
+	for _, name := range names {
+		item := binding[name]
+		fmt.Fprintf(&key, "%q %q %q %q %q\n", name, item.Type, item.Value, item.Lang, item.DataType)
+	}
+	return key.String()
+}
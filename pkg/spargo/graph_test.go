@@ -0,0 +1,57 @@
+package spargo
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+// graphTests describes a row of data for testing graph queries with.
+type graphTests struct {
+	contentType   string
+	responseValue string
+	triples       int
+	expectErr     bool
+}
+
+var graphResults = []graphTests{
+	graphTests{"application/n-triples", testNTriples, 3, false},
+	graphTests{"text/turtle; charset=utf-8", testTurtle, len(expectedTurtle), false},
+	graphTests{"", testTurtle, len(expectedTurtle), false},
+	graphTests{"application/rdf+xml", "<rdf:RDF/>", 0, true},
+	graphTests{"application/n-triples", testTurtle, 0, true},
+}
+
+// TestSparqlGraph makes sure a DESCRIBE query negotiates an RDF format
+// and that the response is parsed according to its content type.
+func TestSparqlGraph(t *testing.T) {
+	for _, val := range graphResults {
+		accept := ""
+		httpClient := NewTestClient(func(req *http.Request) *http.Response {
+			accept = req.Header.Get("Accept")
+			header := make(http.Header)
+			header.Set("Content-Type", val.contentType)
+			return &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(bytes.NewBufferString(val.responseValue)),
+				Header:     header,
+			}
+		})
+
+		sparql := SPARQLClient{}
+		sparql.Client = httpClient
+		sparql.ClientInit("http://example.com", "DESCRIBE <http://www.wikidata.org/entity/Q931783>")
+		graph, err := sparql.SPARQLGraph()
+
+		if accept != DefaultGraphAccept {
+			t.Errorf("Expected Accept '%s', received '%s'", DefaultGraphAccept, accept)
+		}
+		if (err != nil) != val.expectErr {
+			t.Errorf("Unexpected error state for content type '%s': %v", val.contentType, err)
+		}
+		if len(graph) != val.triples {
+			t.Errorf("Expected %d triples for content type '%s', received %d", val.triples, val.contentType, len(graph))
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package spargo
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// integerBounds describes the range of values allowed by each type in
+// the XSD integer family. A nil bound is unlimited.
+type integerBounds struct {
+	min *big.Int
+	max *big.Int
+}
+
+// bound returns a *big.Int for use in integerBounds.
+func bound(value int64) *big.Int {
+	return big.NewInt(value)
+}
+
+// xsdIntegers lists the XSD integer datatypes and their ranges.
+var xsdIntegers = map[string]integerBounds{
+	xsdNamespace + "integer":            {nil, nil},
+	xsdNamespace + "long":               {bound(math.MinInt64), bound(math.MaxInt64)},
+	xsdNamespace + "int":                {bound(math.MinInt32), bound(math.MaxInt32)},
+	xsdNamespace + "short":              {bound(math.MinInt16), bound(math.MaxInt16)},
+	xsdNamespace + "byte":               {bound(math.MinInt8), bound(math.MaxInt8)},
+	xsdNamespace + "nonNegativeInteger": {bound(0), nil},
+	xsdNamespace + "positiveInteger":    {bound(1), nil},
+	xsdNamespace + "nonPositiveInteger": {nil, bound(0)},
+	xsdNamespace + "negativeInteger":    {nil, bound(-1)},
+	xsdNamespace + "unsignedLong":       {bound(0), new(big.Int).SetUint64(math.MaxUint64)},
+	xsdNamespace + "unsignedInt":        {bound(0), bound(math.MaxUint32)},
+	xsdNamespace + "unsignedShort":      {bound(0), bound(math.MaxUint16)},
+	xsdNamespace + "unsignedByte":       {bound(0), bound(math.MaxUint8)},
+}
+
+// Lexical forms of XSD numbers.
+var (
+	integerLexical = regexp.MustCompile(`^[+-]?[0-9]+$`)
+	decimalLexical = regexp.MustCompile(`^[+-]?([0-9]+(\.[0-9]*)?|\.[0-9]+)$`)
+	floatLexical   = regexp.MustCompile(`^([+-]?([0-9]+(\.[0-9]*)?|\.[0-9]+)([eE][+-]?[0-9]+)?|[+-]?INF|NaN)$`)
+)
+
+// dateTimeLexical matches an xsd:dateTime or xsd:date. Years may have
+// more than four digits and may be negative, as is common in Wikidata.
+var dateTimeLexical = regexp.MustCompile(
+	`^([+-]?)([0-9]{4,})-([0-9]{2})-([0-9]{2})` +
+		`(?:T([0-9]{2}):([0-9]{2}):([0-9]{2})(\.[0-9]+)?)?` +
+		`(Z|[+-][0-9]{2}:[0-9]{2})?$`,
+)
+
+// mismatch returns an error describing an item that cannot be
+// converted to the type requested.
+func (item Item) mismatch(want string) error {
+	datatype := item.DataType
+	if datatype == "" {
+		datatype = item.Type
+	}
+	return fmt.Errorf("%w: cannot read '%s' (%s) as %s", ErrDatatypeMismatch, item.Value, datatype, want)
+}
+
+// isLiteral reports whether an item is a literal value.
+func (item Item) isLiteral() bool {
+	return item.Type == "literal" || item.Type == "typed-literal"
+}
+
+// IsInteger reports whether the item's datatype is in the XSD integer
+// family, e.g. xsd:integer, xsd:int or xsd:nonNegativeInteger.
+func (item Item) IsInteger() bool {
+	_, ok := xsdIntegers[item.DataType]
+	return item.isLiteral() && ok
+}
+
+// IsNumeric reports whether the item's datatype is one of the XSD
+// numeric types.
+func (item Item) IsNumeric() bool {
+	if !item.isLiteral() {
+		return false
+	}
+	switch item.DataType {
+	case XSDDecimal, XSDFloat, XSDDouble:
+		return true
+	}
+	return item.IsInteger()
+}
+
+// AsBigInt returns the value of an item from the XSD integer family.
+// The value must be within the range of its datatype.
+func (item Item) AsBigInt() (*big.Int, error) {
+	bounds, ok := xsdIntegers[item.DataType]
+	if !item.isLiteral() || !ok {
+		return nil, item.mismatch("integer")
+	}
+	value := strings.TrimSpace(item.Value)
+	if !integerLexical.MatchString(value) {
+		return nil, item.mismatch("integer")
+	}
+	integer, ok := new(big.Int).SetString(strings.TrimPrefix(value, "+"), 10)
+	if !ok {
+		return nil, item.mismatch("integer")
+	}
+	if (bounds.min != nil && integer.Cmp(bounds.min) < 0) || (bounds.max != nil && integer.Cmp(bounds.max) > 0) {
+		return nil, fmt.Errorf("spargo: '%s' is out of range for %s", item.Value, item.DataType)
+	}
+	return integer, nil
+}
+
+// AsInt returns the value of an item from the XSD integer family as an
+// int64. An error is returned if the value does not fit.
+func (item Item) AsInt() (int64, error) {
+	integer, err := item.AsBigInt()
+	if err != nil {
+		return 0, err
+	}
+	if !integer.IsInt64() {
+		return 0, fmt.Errorf("spargo: '%s' overflows int64", item.Value)
+	}
+	return integer.Int64(), nil
+}
+
+// AsDecimal returns the exact value of an xsd:decimal, or of an item
+// from the XSD integer family.
+func (item Item) AsDecimal() (*big.Rat, error) {
+	if item.IsInteger() {
+		integer, err := item.AsBigInt()
+		if err != nil {
+			return nil, err
+		}
+		return new(big.Rat).SetInt(integer), nil
+	}
+	value := strings.TrimSpace(item.Value)
+	if !item.isLiteral() || item.DataType != XSDDecimal || !decimalLexical.MatchString(value) {
+		return nil, item.mismatch("decimal")
+	}
+	decimal, ok := new(big.Rat).SetString(value)
+	if !ok {
+		return nil, item.mismatch("decimal")
+	}
+	return decimal, nil
+}
+
+// AsFloat returns the value of any XSD numeric item as a float64.
+// Precision may be lost for large integers and decimals.
+func (item Item) AsFloat() (float64, error) {
+	if !item.IsNumeric() {
+		return 0, item.mismatch("float")
+	}
+	if item.DataType != XSDFloat && item.DataType != XSDDouble {
+		decimal, err := item.AsDecimal()
+		if err != nil {
+			return 0, err
+		}
+		float, _ := decimal.Float64()
+		return float, nil
+	}
+	value := strings.TrimSpace(item.Value)
+	if !floatLexical.MatchString(value) {
+		return 0, item.mismatch("float")
+	}
+	switch value {
+	case "INF", "+INF":
+		return math.Inf(1), nil
+	case "-INF":
+		return math.Inf(-1), nil
+	case "NaN":
+		return math.NaN(), nil
+	}
+	bitSize := 64
+	if item.DataType == XSDFloat {
+		bitSize = 32
+	}
+	float, err := strconv.ParseFloat(value, bitSize)
+	if err != nil {
+		return 0, item.mismatch("float")
+	}
+	return float, nil
+}
+
+// AsBool returns the value of an xsd:boolean item.
+func (item Item) AsBool() (bool, error) {
+	if !item.isLiteral() || item.DataType != XSDBoolean {
+		return false, item.mismatch("boolean")
+	}
+	switch strings.TrimSpace(item.Value) {
+	case "true", "1":
+		return true, nil
+	case "false", "0":
+		return false, nil
+	}
+	return false, item.mismatch("boolean")
+}
+
+// AsTime returns the value of an xsd:dateTime, xsd:dateTimeStamp or
+// xsd:date item. Years may be negative or have more than four digits.
+// Wikidata uses a month or day of 00 for dates of low precision, e.g.
+// a year, and these are read as the first month or day. Days past the
+// end of the month are rejected, and 24:00:00 is read as the start of
+// the next day. Values without a timezone are assumed to be UTC.
+func (item Item) AsTime() (time.Time, error) {
+	switch item.DataType {
+	case XSDDateTime, XSDDate, xsdNamespace + "dateTimeStamp":
+	default:
+		return time.Time{}, item.mismatch("time")
+	}
+	if !item.isLiteral() {
+		return time.Time{}, item.mismatch("time")
+	}
+	match := dateTimeLexical.FindStringSubmatch(strings.TrimSpace(item.Value))
+	hasTime := match != nil && match[5] != ""
+	if match == nil || hasTime == (item.DataType == XSDDate) {
+		return time.Time{}, item.mismatch("time")
+	}
+
+	year, err := strconv.Atoi(match[2])
+	if err != nil || len(match[2]) > 11 {
+		return time.Time{}, fmt.Errorf("spargo: year '%s' is out of range", match[2])
+	}
+	if match[1] == "-" {
+		year = -year
+	}
+	fields := make([]int, 5)
+	for i, value := range []string{match[3], match[4], match[5], match[6], match[7]} {
+		if value != "" {
+			fields[i], _ = strconv.Atoi(value)
+		}
+	}
+	month, day, hour, minute, second := fields[0], fields[1], fields[2], fields[3], fields[4]
+	if month == 0 {
+		month = 1
+	}
+	if day == 0 {
+		day = 1
+	}
+	if month > 12 || hour > 24 || minute > 59 || second > 59 {
+		return time.Time{}, item.mismatch("time")
+	}
+	// Day zero of the next month is the last day of this one.
+	if day > time.Date(year, time.Month(month)+1, 0, 0, 0, 0, 0, time.UTC).Day() {
+		return time.Time{}, item.mismatch("time")
+	}
+	nanos := 0
+	if match[8] != "" {
+		fraction := (match[8][1:] + "000000000")[:9]
+		nanos, _ = strconv.Atoi(fraction)
+	}
+	if hour == 24 && (minute != 0 || second != 0 || nanos != 0) {
+		return time.Time{}, item.mismatch("time")
+	}
+
+	location := time.UTC
+	if zone := match[9]; zone != "" && zone != "Z" {
+		hours, _ := strconv.Atoi(zone[1:3])
+		minutes, _ := strconv.Atoi(zone[4:6])
+		offset := hours*3600 + minutes*60
+		if zone[0] == '-' {
+			offset = -offset
+		}
+		location = time.FixedZone(zone, offset)
+	}
+
+	return time.Date(year, time.Month(month), day, hour, minute, second, nanos, location), nil
+}
@@ -0,0 +1,142 @@
+package spargo
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// literal is a helper for creating typed literal items in tests.
+func literal(value string, datatype string) Item {
+	return Item{Type: "literal", Value: value, DataType: datatype}
+}
+
+// TestItemAsInt checks the integer family, range checks and mismatches.
+func TestItemAsInt(t *testing.T) {
+	tests := []struct {
+		item     Item
+		expected int64
+		ok       bool
+	}{
+		{literal("42", XSDInteger), 42, true},
+		{literal("+7", xsdNamespace+"int"), 7, true},
+		{literal("-128", xsdNamespace+"byte"), -128, true},
+		{literal("128", xsdNamespace+"byte"), 0, false},
+		{literal("-1", xsdNamespace+"unsignedInt"), 0, false},
+		{literal("0", xsdNamespace+"positiveInteger"), 0, false},
+		{literal("99999999999999999999", XSDInteger), 0, false},
+		{literal("1.5", XSDDecimal), 0, false},
+		{literal("42", ""), 0, false},
+		{literal("0x2A", XSDInteger), 0, false},
+		{Item{Type: "uri", Value: "42", DataType: XSDInteger}, 0, false},
+	}
+	for _, test := range tests {
+		value, err := test.item.AsInt()
+		if (err == nil) != test.ok || value != test.expected {
+			t.Errorf("AsInt(%+v) = %d, %v", test.item, value, err)
+		}
+	}
+
+	big, err := literal("99999999999999999999", XSDInteger).AsBigInt()
+	if err != nil || big.String() != "99999999999999999999" {
+		t.Errorf("AsBigInt should handle arbitrarily large integers: %v, %v", big, err)
+	}
+
+	_, err = literal("hello", "").AsInt()
+	if !errors.Is(err, ErrDatatypeMismatch) {
+		t.Errorf("Expected ErrDatatypeMismatch, received: %v", err)
+	}
+}
+
+// TestItemAsFloatAndDecimal checks the conversion of XSD numerics.
+func TestItemAsFloatAndDecimal(t *testing.T) {
+	floats := []struct {
+		item     Item
+		expected float64
+		ok       bool
+	}{
+		{literal("1.5e3", XSDDouble), 1500, true},
+		{literal("-INF", XSDFloat), math.Inf(-1), true},
+		{literal("2.25", XSDDecimal), 2.25, true},
+		{literal("3", xsdNamespace+"short"), 3, true},
+		{literal("1e3", XSDDecimal), 0, false},
+		{literal("true", XSDBoolean), 0, false},
+	}
+	for _, test := range floats {
+		value, err := test.item.AsFloat()
+		if (err == nil) != test.ok || value != test.expected {
+			t.Errorf("AsFloat(%+v) = %f, %v", test.item, value, err)
+		}
+	}
+
+	nan, err := literal("NaN", XSDDouble).AsFloat()
+	if err != nil || !math.IsNaN(nan) {
+		t.Errorf("Expected NaN, received %f, %v", nan, err)
+	}
+
+	decimal, err := literal("0.1", XSDDecimal).AsDecimal()
+	if err != nil || decimal.Cmp(big.NewRat(1, 10)) != 0 {
+		t.Errorf("Expected exactly 0.1, received %v, %v", decimal, err)
+	}
+	if _, err := literal("1/10", XSDDecimal).AsDecimal(); err == nil {
+		t.Errorf("Expected an error for a fraction")
+	}
+	if _, err := literal("1.0", XSDDouble).AsDecimal(); err == nil {
+		t.Errorf("Expected an error reading a double as a decimal")
+	}
+}
+
+// TestItemAsBool checks the lexical forms of xsd:boolean.
+func TestItemAsBool(t *testing.T) {
+	for value, expected := range map[string]bool{"true": true, "1": true, "false": false, "0": false} {
+		answer, err := literal(value, XSDBoolean).AsBool()
+		if err != nil || answer != expected {
+			t.Errorf("AsBool(%s) = %t, %v", value, answer, err)
+		}
+	}
+	if _, err := literal("yes", XSDBoolean).AsBool(); err == nil {
+		t.Errorf("Expected an error for an invalid boolean")
+	}
+	if _, err := literal("true", "").AsBool(); err == nil {
+		t.Errorf("Expected an error for an untyped boolean")
+	}
+}
+
+// TestItemAsTime checks dates and times, including those found in
+// Wikidata outside of the usual range.
+func TestItemAsTime(t *testing.T) {
+	tests := []struct {
+		item     Item
+		expected time.Time
+		ok       bool
+	}{
+		{literal("2021-03-01T12:30:15Z", XSDDateTime), time.Date(2021, 3, 1, 12, 30, 15, 0, time.UTC), true},
+		{literal("2021-03-01T12:30:15.25+02:00", XSDDateTime), time.Date(2021, 3, 1, 10, 30, 15, 250000000, time.UTC), true},
+		{literal("1992-06-01", XSDDate), time.Date(1992, 6, 1, 0, 0, 0, 0, time.UTC), true},
+		{literal("-0500-01-01T00:00:00Z", XSDDateTime), time.Date(-500, 1, 1, 0, 0, 0, 0, time.UTC), true},
+		{literal("-13798000000-00-00T00:00:00Z", XSDDateTime), time.Date(-13798000000, 1, 1, 0, 0, 0, 0, time.UTC), true},
+		{literal("2021-03-01", XSDDateTime), time.Time{}, false},
+		{literal("2021-03-01T12:30:15Z", XSDDate), time.Time{}, false},
+		{literal("2021-13-01T00:00:00Z", XSDDateTime), time.Time{}, false},
+		{literal("999999999999-01-01T00:00:00Z", XSDDateTime), time.Time{}, false},
+		{literal("2021-03-01T12:30:15Z", ""), time.Time{}, false},
+		{literal("2020-02-29", XSDDate), time.Date(2020, 2, 29, 0, 0, 0, 0, time.UTC), true},
+		{literal("2021-02-29", XSDDate), time.Time{}, false},
+		{literal("2021-02-30", XSDDate), time.Time{}, false},
+		{literal("2021-04-31", XSDDate), time.Time{}, false},
+		{literal("2021-01-32T00:00:00Z", XSDDateTime), time.Time{}, false},
+		{literal("2021-02-00", XSDDate), time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC), true},
+		{literal("2021-12-31T24:00:00Z", XSDDateTime), time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC), true},
+		{literal("2021-01-01T24:30:00Z", XSDDateTime), time.Time{}, false},
+		{literal("2021-01-01T24:00:00.5Z", XSDDateTime), time.Time{}, false},
+		{literal("2021-01-01T12:00:60Z", XSDDateTime), time.Time{}, false},
+	}
+	for _, test := range tests {
+		value, err := test.item.AsTime()
+		if (err == nil) != test.ok || !value.Equal(test.expected) {
+			t.Errorf("AsTime(%+v) = %s, %v", test.item, value, err)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package spargo
+
+import (
+	"strings"
+	"unicode"
+)
+
+// segmentKind describes a stretch of a SPARQL query.
+type segmentKind int
+
+// Kinds of segment found in a SPARQL query.
+const (
+	segmentCode segmentKind = iota
+	segmentString
+	segmentIRI
+	segmentComment
+)
+
+// segment is a stretch of a query of a single kind.
+type segment struct {
+	kind segmentKind
+	text string
+}
+
+// scanQuery splits a query into strings, IRIs, comments and everything
+// else, so that we can look for variables and prefixed names without
+// being fooled by text that happens to be quoted. It doesn't validate
+// the query, that is left to the endpoint.
+func scanQuery(query string) []segment {
+	var segments []segment
+	runes := []rune(query)
+	start := 0
+	add := func(kind segmentKind, end int) {
+		if end > start {
+			segments = append(segments, segment{kind: kind, text: string(runes[start:end])})
+		}
+		start = end
+	}
+	for pos := 0; pos < len(runes); {
+		char := runes[pos]
+		switch {
+		case char == '#':
+			add(segmentCode, pos)
+			end := pos
+			for end < len(runes) && runes[end] != '\n' {
+				end++
+			}
+			add(segmentComment, end)
+			pos = end
+		case char == '"' || char == '\'':
+			add(segmentCode, pos)
+			end := scanString(runes, pos)
+			add(segmentString, end)
+			pos = end
+		case char == '<':
+			end, ok := scanIRI(runes, pos)
+			if !ok {
+				// A less-than operator.
+				pos++
+				continue
+			}
+			add(segmentCode, pos)
+			add(segmentIRI, end)
+			pos = end
+		default:
+			pos++
+		}
+	}
+	add(segmentCode, len(runes))
+	return segments
+}
+
+// NormalizeSpace returns a query with each run of white space outside
+// its strings, IRIs and comments replaced by a single space, so that
+// queries that differ only in layout can be compared. A comment is
+// still followed by a new line, which ends it.
+func NormalizeSpace(query string) string {
+	var normalized strings.Builder
+	afterComment := false
+	for _, seg := range scanQuery(query) {
+		if seg.kind != segmentCode {
+			normalized.WriteString(seg.text)
+			afterComment = seg.kind == segmentComment
+			continue
+		}
+		runes := []rune(seg.text)
+		switch {
+		case afterComment:
+			normalized.WriteString("\n")
+		case unicode.IsSpace(runes[0]):
+			normalized.WriteString(" ")
+		}
+		fields := strings.Fields(seg.text)
+		normalized.WriteString(strings.Join(fields, " "))
+		if len(fields) > 0 && unicode.IsSpace(runes[len(runes)-1]) {
+			normalized.WriteString(" ")
+		}
+		afterComment = false
+	}
+	return strings.TrimSpace(normalized.String())
+}
+
+// scanString returns the position after the string starting at pos.
+// Unterminated strings run to the end of the query.
+func scanString(runes []rune, pos int) int {
+	quote := runes[pos]
+	long := pos+2 < len(runes) && runes[pos+1] == quote && runes[pos+2] == quote
+	if long {
+		pos += 3
+	} else {
+		pos++
+	}
+	for pos < len(runes) {
+		switch {
+		case runes[pos] == '\\':
+			pos += 2
+			continue
+		case runes[pos] != quote:
+		case !long:
+			return pos + 1
+		case pos+2 < len(runes) && runes[pos+1] == quote && runes[pos+2] == quote:
+			// Allow for quotes at the very end of the string.
+			end := pos + 3
+			for end < len(runes) && runes[end] == quote {
+				end++
+			}
+			return end
+		}
+		pos++
+	}
+	return len(runes)
+}
+
+// scanIRI returns the position after the IRI reference starting at pos,
+// and false if the '<' does not start an IRI reference.
+func scanIRI(runes []rune, pos int) (int, bool) {
+	for end := pos + 1; end < len(runes); end++ {
+		char := runes[end]
+		if char == '>' {
+			return end + 1, true
+		}
+		if char <= 0x20 || strings.ContainsRune("<\"{}|^`\\", char) {
+			return 0, false
+		}
+	}
+	return 0, false
+}
+
+// isVarChar reports whether a character can appear in a variable name.
+func isVarChar(char rune) bool {
+	return char == '_' || char == 0xB7 || unicode.IsLetter(char) || unicode.IsDigit(char) ||
+		(char >= 0x300 && char <= 0x36F) || (char >= 0x203F && char <= 0x2040)
+}
@@ -0,0 +1,25 @@
+package spargo
+
+import (
+	"testing"
+)
+
+// TestNormalizeSpace makes sure white space is only collapsed outside
+// strings, IRIs and comments.
+func TestNormalizeSpace(t *testing.T) {
+	tests := map[string]string{
+		"SELECT *\n\tWHERE {  ?s ?p ?o }  ":   "SELECT * WHERE { ?s ?p ?o }",
+		`FILTER(?l = "a  b")`:                 `FILTER(?l = "a  b")`,
+		"FILTER(?l = 'a\tb' )":                "FILTER(?l = 'a\tb' )",
+		"?s  <http://example.com/a>  ?o":      "?s <http://example.com/a> ?o",
+		"# a  comment\n  ?s ?p ?o":            "# a  comment\n?s ?p ?o",
+		"# a comment ?s\n?p ?o":               "# a comment ?s\n?p ?o",
+		"FILTER(?x  <  3)":                    "FILTER(?x < 3)",
+		`BIND("""a` + "\n\n" + `b"""  AS ?x)`: `BIND("""a` + "\n\n" + `b""" AS ?x)`,
+	}
+	for query, expected := range tests {
+		if normalized := NormalizeSpace(query); normalized != expected {
+			t.Errorf("NormalizeSpace(%q) = %q, expected %q", query, normalized, expected)
+		}
+	}
+}
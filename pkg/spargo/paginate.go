@@ -0,0 +1,196 @@
+package spargo
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// Patterns used to check that a query can be paginated. They are
+// matched against the code of a query, without strings, IRIs and
+// comments.
+var (
+	selectPattern   = regexp.MustCompile(`(?i)\bSELECT\b`)
+	orderByPattern  = regexp.MustCompile(`(?i)\bORDER\s+BY\b`)
+	sliceModPattern = regexp.MustCompile(`(?i)\b(LIMIT|OFFSET)\b`)
+)
+
+// Paginator runs a SELECT query a page at a time using LIMIT and
+// OFFSET, so that a large result can be retrieved without any single
+// request running into the endpoint's timeout. It is used as follows:
+//
+//	pages, err := sparqlMe.SPARQLPaginate(ctx, 1000, 0)
+//	...
+//	for pages.Next() {
+//		binding := pages.Binding()
+//		...
+//	}
+//	if err := pages.Err(); err != nil {
+//		...
+//	}
+//
+// Pages are requested as the rows of the previous page are used up.
+type Paginator struct {
+	ctx      context.Context
+	endpoint *SPARQLClient
+	query    string
+	pageSize int
+	maxRows  int
+	options  queryOptions
+
+	head    map[string]interface{}
+	page    []map[string]Item
+	pages   int
+	offset  int
+	done    bool
+	binding map[string]Item
+	err     error
+}
+
+// SPARQLPaginate returns a Paginator over the results of the client's
+// query, requested pageSize rows at a time. No more than maxRows rows
+// are returned if it is greater than zero. The query must be a SELECT
+// query with an ORDER BY clause, so that the rows on each page are
+// stable, and without a LIMIT or OFFSET of its own. ErrNotPaginable is
+// returned otherwise. The first page is requested straight away. Any
+// options are used for every page.
+func (endpoint *SPARQLClient) SPARQLPaginate(ctx context.Context, pageSize int, maxRows int, opts ...QueryOption) (*Paginator, error) {
+	if pageSize < 1 {
+		return nil, fmt.Errorf("spargo: page size must be at least 1: %d", pageSize)
+	}
+	if err := checkPaginable(endpoint.Query); err != nil {
+		return nil, err
+	}
+	paginator := &Paginator{
+		ctx:      ctx,
+		endpoint: endpoint,
+		query:    endpoint.Query,
+		pageSize: pageSize,
+		maxRows:  maxRows,
+		options:  applyOptions(opts),
+	}
+	if err := paginator.fetchPage(); err != nil {
+		return nil, err
+	}
+	return paginator, nil
+}
+
+// checkPaginable makes sure LIMIT and OFFSET can be added to a query.
+// The solution modifiers of a query follow its last closing brace.
+func checkPaginable(query string) error {
+	var code strings.Builder
+	for _, seg := range scanQuery(query) {
+		if seg.kind == segmentCode {
+			code.WriteString(seg.text)
+		} else {
+			code.WriteString(" ")
+		}
+	}
+	text := code.String()
+	modifiers := text[strings.LastIndex(text, "}")+1:]
+	switch {
+	case !selectPattern.MatchString(text):
+		return fmt.Errorf("%w: not a SELECT query", ErrNotPaginable)
+	case !orderByPattern.MatchString(modifiers):
+		return fmt.Errorf("%w: no ORDER BY clause", ErrNotPaginable)
+	case sliceModPattern.MatchString(modifiers):
+		return fmt.Errorf("%w: query has its own LIMIT or OFFSET", ErrNotPaginable)
+	}
+	return nil
+}
+
+// fetchPage requests the next page of results.
+func (paginator *Paginator) fetchPage() error {
+	limit := paginator.pageSize
+	if paginator.maxRows > 0 && paginator.maxRows-paginator.offset < limit {
+		limit = paginator.maxRows - paginator.offset
+	}
+	if limit <= 0 {
+		paginator.done = true
+		return nil
+	}
+	pageQuery := fmt.Sprintf("%s\nLIMIT %d\nOFFSET %d", paginator.query, limit, paginator.offset)
+	endpoint := paginator.endpoint
+	var res SPARQLResult
+	decode := func(header http.Header, body []byte) (err error) {
+		res, err = decodeResults(header, body)
+		return err
+	}
+	if _, err := endpoint.fetchQuery(paginator.ctx, pageQuery, endpoint.Accept, paginator.options, decode); err != nil {
+		return err
+	}
+	if paginator.head == nil {
+		paginator.head = res.Head
+	}
+	paginator.page = res.Results.Bindings
+	paginator.pages++
+	paginator.offset += len(paginator.page)
+	// A short page means there are no more results.
+	if len(paginator.page) < limit {
+		paginator.done = true
+	}
+	return nil
+}
+
+// Next advances the paginator to the next binding, requesting the next
+// page if needed. It returns false when there are no more bindings or
+// an error occurs.
+func (paginator *Paginator) Next() bool {
+	paginator.binding = nil
+	if paginator.err != nil {
+		return false
+	}
+	for len(paginator.page) == 0 {
+		if paginator.done {
+			return false
+		}
+		if err := paginator.fetchPage(); err != nil {
+			paginator.err = err
+			return false
+		}
+	}
+	paginator.binding = paginator.page[0]
+	paginator.page = paginator.page[1:]
+	return true
+}
+
+// Binding returns the binding Next advanced to.
+func (paginator *Paginator) Binding() map[string]Item {
+	return paginator.binding
+}
+
+// Vars returns the variables listed in the head of the first page.
+func (paginator *Paginator) Vars() []string {
+	return headVars(paginator.head)
+}
+
+// Head returns the head of the first page as it appears in
+// SPARQLResult.
+func (paginator *Paginator) Head() map[string]interface{} {
+	return paginator.head
+}
+
+// Pages returns the number of pages requested so far.
+func (paginator *Paginator) Pages() int {
+	return paginator.pages
+}
+
+// Err returns the error, if any, that stopped the paginator.
+func (paginator *Paginator) Err() error {
+	return paginator.err
+}
+
+// Result reads the remaining bindings from every page and merges them
+// into a single SPARQLResult.
+func (paginator *Paginator) Result() (SPARQLResult, error) {
+	res := SPARQLResult{Head: paginator.head, Results: Binding{Bindings: []map[string]Item{}}}
+	for paginator.Next() {
+		res.Results.Bindings = append(res.Results.Bindings, paginator.Binding())
+	}
+	if err := paginator.Err(); err != nil {
+		return SPARQLResult{}, err
+	}
+	return res, nil
+}
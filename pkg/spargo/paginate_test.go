@@ -0,0 +1,131 @@
+package spargo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+// pagePattern finds the LIMIT and OFFSET added to a paginated query.
+var pagePattern = regexp.MustCompile(`LIMIT (\d+)\nOFFSET (\d+)$`)
+
+// newPagingClient returns a test client serving rows of results a page
+// at a time, recording the queries it receives.
+func newPagingClient(rows int, queries *[]string) *http.Client {
+	return NewTestClient(func(req *http.Request) *http.Response {
+		query := req.URL.Query().Get("query")
+		*queries = append(*queries, query)
+		match := pagePattern.FindStringSubmatch(query)
+		limit, _ := strconv.Atoi(match[1])
+		offset, _ := strconv.Atoi(match[2])
+		res := SPARQLResult{
+			Head:    map[string]interface{}{"vars": []interface{}{"n"}},
+			Results: Binding{Bindings: []map[string]Item{}},
+		}
+		for n := offset; n < offset+limit && n < rows; n++ {
+			res.Results.Bindings = append(res.Results.Bindings, map[string]Item{
+				"n": {Type: "literal", Value: strconv.Itoa(n), DataType: XSDInteger},
+			})
+		}
+		body, _ := json.Marshal(res)
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(bytes.NewBuffer(body)),
+			Header:     make(http.Header),
+		}
+	})
+}
+
+const testPagedQuery = "SELECT ?n WHERE { ?s <http://example.com/n> ?n } ORDER BY ?n"
+
+// TestPaginate makes sure pages are requested until a short page is
+// received, or the maximum number of rows is reached.
+func TestPaginate(t *testing.T) {
+	tests := []struct {
+		rows    int
+		maxRows int
+		queries []string
+	}{
+		{7, 0, []string{"LIMIT 3\nOFFSET 0", "LIMIT 3\nOFFSET 3", "LIMIT 3\nOFFSET 6"}},
+		{6, 0, []string{"LIMIT 3\nOFFSET 0", "LIMIT 3\nOFFSET 3", "LIMIT 3\nOFFSET 6"}},
+		{7, 5, []string{"LIMIT 3\nOFFSET 0", "LIMIT 2\nOFFSET 3"}},
+		{0, 0, []string{"LIMIT 3\nOFFSET 0"}},
+	}
+	for _, test := range tests {
+		var queries []string
+		sparql := SPARQLClient{}
+		sparql.ClientInit("http://example.com/sparql", testPagedQuery)
+		sparql.Client = newPagingClient(test.rows, &queries)
+		pages, err := sparql.SPARQLPaginate(context.Background(), 3, test.maxRows)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		res, err := pages.Result()
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		expected := test.rows
+		if test.maxRows > 0 && test.maxRows < expected {
+			expected = test.maxRows
+		}
+		if len(res.Results.Bindings) != expected {
+			t.Errorf("Expected %d rows, received %d", expected, len(res.Results.Bindings))
+		}
+		for n, binding := range res.Results.Bindings {
+			if binding["n"].Value != strconv.Itoa(n) {
+				t.Errorf("Expected row %d, received %s", n, binding["n"].Value)
+			}
+		}
+		if fmt.Sprint(pages.Vars()) != "[n]" {
+			t.Errorf("Unexpected vars: %v", pages.Vars())
+		}
+		if pages.Pages() != len(test.queries) || len(queries) != len(test.queries) {
+			t.Errorf("Expected %d pages, received %d", len(test.queries), len(queries))
+			continue
+		}
+		for i, query := range queries {
+			if query != testPagedQuery+"\n"+test.queries[i] {
+				t.Errorf("Unexpected query for page %d:\n%s", i, query)
+			}
+		}
+		if sparql.Query != testPagedQuery {
+			t.Errorf("Expected the client's query to be left alone")
+		}
+	}
+}
+
+// TestPaginateErrors makes sure queries that cannot be paginated
+// reliably are rejected before anything is sent.
+func TestPaginateErrors(t *testing.T) {
+	queries := []string{
+		"SELECT ?n WHERE { ?s ?p ?n }",
+		"SELECT ?n WHERE { ?s ?p ?n } ORDER BY ?n LIMIT 10",
+		"SELECT ?n WHERE { { SELECT ?n WHERE { ?s ?p ?n } ORDER BY ?n } } # ORDER BY ?n",
+		"ASK { ?s ?p ?o } ORDER BY ?o",
+	}
+	for _, query := range queries {
+		var sent []string
+		sparql := SPARQLClient{}
+		sparql.ClientInit("http://example.com/sparql", query)
+		sparql.Client = newPagingClient(1, &sent)
+		_, err := sparql.SPARQLPaginate(context.Background(), 10, 0)
+		if !errors.Is(err, ErrNotPaginable) {
+			t.Errorf("Expected ErrNotPaginable for query '%s', received: %v", query, err)
+		}
+		if len(sent) != 0 {
+			t.Errorf("Expected no requests for query '%s'", query)
+		}
+	}
+	sparql := SPARQLClient{}
+	sparql.ClientInit("http://example.com/sparql", testPagedQuery)
+	if _, err := sparql.SPARQLPaginate(context.Background(), 0, 0); err == nil {
+		t.Errorf("Expected an error for a page size of zero")
+	}
+}
@@ -0,0 +1,254 @@
+package spargo
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// langTag validates the language tags of literals.
+var langTag = regexp.MustCompile(`^[a-zA-Z]+(-[a-zA-Z0-9]+)*$`)
+
+// ParameterizedQuery is a query template with variables that can be
+// bound to values safely, in the manner of Jena's
+// ParameterizedSparqlString. Values are written into the query as
+// escaped literals or validated IRIs, so user supplied input cannot
+// change the structure of the query. For example:
+//
+//	pq := spargo.NewParameterizedQuery(`SELECT ?uri WHERE { ?uri wdt:P2748 ?puid }`)
+//	pq.SetString("puid", userInput)
+//	sparqlMe.SetParameterizedQuery(pq)
+//
+// Every occurrence of a bound variable, written either as ?puid or
+// $puid, is replaced, except within strings, IRIs and comments. Unbound
+// variables are left as they are.
+type ParameterizedQuery struct {
+	template string
+	params   map[string]string
+}
+
+// NewParameterizedQuery returns a ParameterizedQuery for a template.
+func NewParameterizedQuery(template string) *ParameterizedQuery {
+	return &ParameterizedQuery{template: template, params: make(map[string]string)}
+}
+
+// validVar checks the name of a variable being bound.
+func validVar(name string) (string, error) {
+	name = strings.TrimLeft(name, "?$")
+	if name == "" {
+		return "", fmt.Errorf("spargo: empty variable name")
+	}
+	for _, char := range name {
+		if !isVarChar(char) {
+			return "", fmt.Errorf("spargo: invalid variable name: '%s'", name)
+		}
+	}
+	return name, nil
+}
+
+// set records the rendered value of a variable.
+func (pq *ParameterizedQuery) set(name string, rendered string) error {
+	name, err := validVar(name)
+	if err != nil {
+		return err
+	}
+	pq.params[name] = rendered
+	return nil
+}
+
+// ValidateIRI makes sure an IRI is absolute and contains none of the
+// characters that SPARQL excludes from IRI references.
+func ValidateIRI(iri string) error {
+	for _, char := range iri {
+		if illegalIRIChar(char) {
+			return fmt.Errorf("spargo: invalid character %q in IRI: '%s'", char, iri)
+		}
+	}
+	parsed, err := url.Parse(iri)
+	if err != nil || parsed.Scheme == "" {
+		return fmt.Errorf("spargo: IRI is not absolute: '%s'", iri)
+	}
+	return nil
+}
+
+// ValidateLangTag makes sure a language tag has the form allowed in
+// SPARQL, e.g. en or en-GB.
+func ValidateLangTag(lang string) error {
+	if !langTag.MatchString(lang) {
+		return fmt.Errorf("spargo: invalid language tag: '%s'", lang)
+	}
+	return nil
+}
+
+// SetIRI binds a variable to an IRI, which must be absolute.
+func (pq *ParameterizedQuery) SetIRI(name string, iri string) error {
+	if err := ValidateIRI(iri); err != nil {
+		return err
+	}
+	return pq.set(name, "<"+iri+">")
+}
+
+// SetString binds a variable to a simple string literal.
+func (pq *ParameterizedQuery) SetString(name string, value string) error {
+	return pq.set(name, Literal{Value: value}.String())
+}
+
+// SetLangString binds a variable to a string literal with a language
+// tag, e.g. "JPEG 2000"@en.
+func (pq *ParameterizedQuery) SetLangString(name string, value string, lang string) error {
+	if err := ValidateLangTag(lang); err != nil {
+		return err
+	}
+	return pq.set(name, Literal{Value: value, Lang: lang}.String())
+}
+
+// SetTypedLiteral binds a variable to a literal with a datatype, e.g.
+// SetTypedLiteral("offset", "4", XSDInteger).
+func (pq *ParameterizedQuery) SetTypedLiteral(name string, value string, datatype string) error {
+	if err := ValidateIRI(datatype); err != nil {
+		return err
+	}
+	return pq.set(name, Literal{Value: value, DataType: datatype}.String())
+}
+
+// SetTerm binds a variable to an RDF term.
+func (pq *ParameterizedQuery) SetTerm(name string, term Term) error {
+	switch value := term.(type) {
+	case IRI:
+		return pq.SetIRI(name, string(value))
+	case Literal:
+		switch {
+		case value.Lang != "":
+			return pq.SetLangString(name, value.Value, value.Lang)
+		case value.DataType != "":
+			return pq.SetTypedLiteral(name, value.Value, value.DataType)
+		}
+		return pq.SetString(name, value.Value)
+	}
+	return fmt.Errorf("spargo: cannot bind %T to a variable", term)
+}
+
+// SetLiteral binds a variable to a Go value written as a typed literal.
+// Strings, booleans, integers, floats, *big.Int, *big.Rat and time.Time
+// are supported, as are the Term types.
+func (pq *ParameterizedQuery) SetLiteral(name string, value interface{}) error {
+	switch typed := value.(type) {
+	case Term:
+		return pq.SetTerm(name, typed)
+	case string:
+		return pq.SetString(name, typed)
+	case bool:
+		return pq.SetTypedLiteral(name, strconv.FormatBool(typed), XSDBoolean)
+	case int:
+		return pq.SetTypedLiteral(name, strconv.FormatInt(int64(typed), 10), XSDInteger)
+	case int8:
+		return pq.SetTypedLiteral(name, strconv.FormatInt(int64(typed), 10), XSDInteger)
+	case int16:
+		return pq.SetTypedLiteral(name, strconv.FormatInt(int64(typed), 10), XSDInteger)
+	case int32:
+		return pq.SetTypedLiteral(name, strconv.FormatInt(int64(typed), 10), XSDInteger)
+	case int64:
+		return pq.SetTypedLiteral(name, strconv.FormatInt(typed, 10), XSDInteger)
+	case uint:
+		return pq.SetTypedLiteral(name, strconv.FormatUint(uint64(typed), 10), XSDInteger)
+	case uint8:
+		return pq.SetTypedLiteral(name, strconv.FormatUint(uint64(typed), 10), XSDInteger)
+	case uint16:
+		return pq.SetTypedLiteral(name, strconv.FormatUint(uint64(typed), 10), XSDInteger)
+	case uint32:
+		return pq.SetTypedLiteral(name, strconv.FormatUint(uint64(typed), 10), XSDInteger)
+	case uint64:
+		return pq.SetTypedLiteral(name, strconv.FormatUint(typed, 10), XSDInteger)
+	case float32:
+		return pq.SetTypedLiteral(name, formatXSDFloat(float64(typed), 32), XSDFloat)
+	case float64:
+		return pq.SetTypedLiteral(name, formatXSDFloat(typed, 64), XSDDouble)
+	case *big.Int:
+		return pq.SetTypedLiteral(name, typed.String(), XSDInteger)
+	case *big.Rat:
+		return pq.SetTypedLiteral(name, formatXSDDecimal(typed), XSDDecimal)
+	case time.Time:
+		return pq.SetTypedLiteral(name, typed.Format(time.RFC3339Nano), XSDDateTime)
+	}
+	return fmt.Errorf("spargo: cannot bind %T to a variable", value)
+}
+
+// formatXSDFloat writes a float in the lexical form used by XSD.
+func formatXSDFloat(value float64, bitSize int) string {
+	switch {
+	case math.IsNaN(value):
+		return "NaN"
+	case math.IsInf(value, 1):
+		return "INF"
+	case math.IsInf(value, -1):
+		return "-INF"
+	}
+	return strconv.FormatFloat(value, 'E', -1, bitSize)
+}
+
+// formatXSDDecimal writes a rational number as an xsd:decimal. Values
+// that cannot be written exactly are rounded to 20 decimal places.
+func formatXSDDecimal(value *big.Rat) string {
+	if value.IsInt() {
+		return value.Num().String()
+	}
+	return strings.TrimRight(value.FloatString(20), "0")
+}
+
+// Clear removes the binding for a variable.
+func (pq *ParameterizedQuery) Clear(name string) {
+	delete(pq.params, strings.TrimLeft(name, "?$"))
+}
+
+// Bound returns the names of the variables that have been bound.
+func (pq *ParameterizedQuery) Bound() []string {
+	names := make([]string, 0, len(pq.params))
+	for name := range pq.params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// String returns the query with bound variables replaced by their
+// values.
+func (pq *ParameterizedQuery) String() string {
+	var builder strings.Builder
+	for _, part := range scanQuery(pq.template) {
+		if part.kind != segmentCode {
+			builder.WriteString(part.text)
+			continue
+		}
+		builder.WriteString(pq.substitute(part.text))
+	}
+	return builder.String()
+}
+
+// substitute replaces bound variables in a stretch of code.
+func (pq *ParameterizedQuery) substitute(code string) string {
+	runes := []rune(code)
+	var builder strings.Builder
+	for pos := 0; pos < len(runes); {
+		char := runes[pos]
+		if (char == '?' || char == '$') && (pos == 0 || !isVarChar(runes[pos-1])) {
+			end := pos + 1
+			for end < len(runes) && isVarChar(runes[end]) {
+				end++
+			}
+			if value, ok := pq.params[string(runes[pos+1:end])]; ok && end > pos+1 {
+				builder.WriteString(value)
+				pos = end
+				continue
+			}
+		}
+		builder.WriteRune(char)
+		pos++
+	}
+	return builder.String()
+}
@@ -0,0 +1,99 @@
+package spargo
+
+import (
+	"math/big"
+	"testing"
+	"time"
+)
+
+var testTemplate = `# Look up ?puid by its PUID.
+SELECT ?uri ?label WHERE {
+	?uri <http://www.wikidata.org/prop/direct/P2748> ?puid ;
+	     <http://www.w3.org/2000/01/rdf-schema#label> ?label .
+	FILTER(?label != "?puid" && $puid != ?other)
+}`
+
+// TestParameterizedQuery makes sure bound variables are replaced
+// everywhere except in strings, IRIs and comments.
+func TestParameterizedQuery(t *testing.T) {
+	pq := NewParameterizedQuery(testTemplate)
+	if err := pq.SetString("puid", `fmt/134" } DROP ALL # `); err != nil {
+		t.Fatalf("Unexpected error binding string: %s", err)
+	}
+	expected := `# Look up ?puid by its PUID.
+SELECT ?uri ?label WHERE {
+	?uri <http://www.wikidata.org/prop/direct/P2748> "fmt/134\" } DROP ALL # " ;
+	     <http://www.w3.org/2000/01/rdf-schema#label> ?label .
+	FILTER(?label != "?puid" && "fmt/134\" } DROP ALL # " != ?other)
+}`
+	if pq.String() != expected {
+		t.Errorf("Unexpected query:\n%s", pq)
+	}
+
+	sparql := SPARQLClient{}
+	sparql.ClientInit("http://example.com", "")
+	sparql.SetParameterizedQuery(pq)
+	if sparql.Query != expected {
+		t.Errorf("Expected the client query to be set from the template")
+	}
+
+	pq.Clear("?puid")
+	if pq.String() != testTemplate {
+		t.Errorf("Expected the template once the binding is cleared:\n%s", pq)
+	}
+}
+
+// TestParameterizedValues checks how Go values are written.
+func TestParameterizedValues(t *testing.T) {
+	tests := []struct {
+		value    interface{}
+		expected string
+	}{
+		{"Say \"cheese\"\n", `"Say \"cheese\"\n"`},
+		{42, `"42"^^<http://www.w3.org/2001/XMLSchema#integer>`},
+		{uint8(7), `"7"^^<http://www.w3.org/2001/XMLSchema#integer>`},
+		{true, `"true"^^<http://www.w3.org/2001/XMLSchema#boolean>`},
+		{1.5, `"1.5E+00"^^<http://www.w3.org/2001/XMLSchema#double>`},
+		{big.NewRat(1, 4), `"0.25"^^<http://www.w3.org/2001/XMLSchema#decimal>`},
+		{time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC), `"2021-03-01T12:00:00Z"^^<http://www.w3.org/2001/XMLSchema#dateTime>`},
+		{IRI("http://www.wikidata.org/entity/Q931783"), `<http://www.wikidata.org/entity/Q931783>`},
+		{Literal{Value: "JPEG", Lang: "en"}, `"JPEG"@en`},
+	}
+	for _, test := range tests {
+		pq := NewParameterizedQuery("SELECT * WHERE { ?s ?p $o }")
+		if err := pq.SetLiteral("o", test.value); err != nil {
+			t.Errorf("Unexpected error binding %v: %s", test.value, err)
+			continue
+		}
+		expected := "SELECT * WHERE { ?s ?p " + test.expected + " }"
+		if pq.String() != expected {
+			t.Errorf("Binding %v: expected '%s', received '%s'", test.value, expected, pq)
+		}
+	}
+}
+
+// TestParameterizedErrors makes sure unsafe values are rejected.
+func TestParameterizedErrors(t *testing.T) {
+	pq := NewParameterizedQuery("SELECT * WHERE { ?s ?p ?o }")
+	if err := pq.SetIRI("o", "http://example.com/> . } DROP ALL #"); err == nil {
+		t.Errorf("Expected an error binding an IRI containing '>'")
+	}
+	if err := pq.SetIRI("o", "relative/path"); err == nil {
+		t.Errorf("Expected an error binding a relative IRI")
+	}
+	if err := pq.SetLangString("o", "JPEG", "en\" . }"); err == nil {
+		t.Errorf("Expected an error binding an invalid language tag")
+	}
+	if err := pq.SetTypedLiteral("o", "5", "integer"); err == nil {
+		t.Errorf("Expected an error binding a datatype that isn't an absolute IRI")
+	}
+	if err := pq.SetString("o o", "x"); err == nil {
+		t.Errorf("Expected an error binding an invalid variable name")
+	}
+	if err := pq.SetLiteral("o", struct{}{}); err == nil {
+		t.Errorf("Expected an error binding an unsupported type")
+	}
+	if len(pq.Bound()) != 0 {
+		t.Errorf("Expected nothing to be bound, received: %v", pq.Bound())
+	}
+}
@@ -0,0 +1,187 @@
+package spargo
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// wellKnownPrefixes are the prefixes bundled with every PrefixRegistry.
+// The Wikidata prefixes are those predeclared by the Wikidata Query
+// Service.
+var wellKnownPrefixes = map[string]string{
+	"rdf":     rdfNamespace,
+	"rdfs":    "http://www.w3.org/2000/01/rdf-schema#",
+	"owl":     "http://www.w3.org/2002/07/owl#",
+	"xsd":     xsdNamespace,
+	"schema":  "http://schema.org/",
+	"dcterms": "http://purl.org/dc/terms/",
+	"skos":    "http://www.w3.org/2004/02/skos/core#",
+	"prov":    "http://www.w3.org/ns/prov#",
+
+	"wd":       "http://www.wikidata.org/entity/",
+	"wds":      "http://www.wikidata.org/entity/statement/",
+	"wdv":      "http://www.wikidata.org/value/",
+	"wdref":    "http://www.wikidata.org/reference/",
+	"wdt":      "http://www.wikidata.org/prop/direct/",
+	"wdtn":     "http://www.wikidata.org/prop/direct-normalized/",
+	"wdno":     "http://www.wikidata.org/prop/novalue/",
+	"wdata":    "http://www.wikidata.org/wiki/Special:EntityData/",
+	"wikibase": "http://wikiba.se/ontology#",
+	"p":        "http://www.wikidata.org/prop/",
+	"ps":       "http://www.wikidata.org/prop/statement/",
+	"psv":      "http://www.wikidata.org/prop/statement/value/",
+	"psn":      "http://www.wikidata.org/prop/statement/value-normalized/",
+	"pq":       "http://www.wikidata.org/prop/qualifier/",
+	"pqv":      "http://www.wikidata.org/prop/qualifier/value/",
+	"pqn":      "http://www.wikidata.org/prop/qualifier/value-normalized/",
+	"pr":       "http://www.wikidata.org/prop/reference/",
+	"prv":      "http://www.wikidata.org/prop/reference/value/",
+	"prn":      "http://www.wikidata.org/prop/reference/value-normalized/",
+	"bd":       "http://www.bigdata.com/rdf#",
+}
+
+// prefixDeclaration matches the PREFIX declarations in the code of a
+// query.
+var prefixDeclaration = regexp.MustCompile(`(?i)(?:^|[^\w?$:])PREFIX\s+([^\s:]*):`)
+
+// PrefixRegistry maps prefix names to namespace IRIs so that queries
+// can use prefixed names without declaring them. A PrefixRegistry is
+// safe for concurrent use and can be shared between SPARQLClients.
+type PrefixRegistry struct {
+	mutex      sync.RWMutex
+	namespaces map[string]string
+}
+
+// NewPrefixRegistry returns a PrefixRegistry holding the well-known
+// prefixes: rdf, rdfs, owl, xsd, schema, dcterms, skos, prov and those
+// predeclared by Wikidata, e.g. wd, wdt, p, ps, pq and wikibase.
+func NewPrefixRegistry() *PrefixRegistry {
+	registry := &PrefixRegistry{namespaces: map[string]string{}}
+	for prefix, namespace := range wellKnownPrefixes {
+		registry.namespaces[prefix] = namespace
+	}
+	return registry
+}
+
+// Register adds a prefix to the registry, replacing any namespace it
+// was already mapped to. The empty prefix may be registered, it is
+// used by names such as :item.
+func (registry *PrefixRegistry) Register(prefix string, namespace string) error {
+	if !validPrefix(prefix) {
+		return fmt.Errorf("spargo: invalid prefix name: '%s'", prefix)
+	}
+	if err := ValidateIRI(namespace); err != nil {
+		return err
+	}
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	if registry.namespaces == nil {
+		registry.namespaces = map[string]string{}
+	}
+	registry.namespaces[prefix] = namespace
+	return nil
+}
+
+// Namespace returns the namespace IRI a prefix is mapped to.
+func (registry *PrefixRegistry) Namespace(prefix string) (string, bool) {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+	namespace, ok := registry.namespaces[prefix]
+	return namespace, ok
+}
+
+// Prefixes returns the registered prefix names in sorted order.
+func (registry *PrefixRegistry) Prefixes() []string {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+	prefixes := make([]string, 0, len(registry.namespaces))
+	for prefix := range registry.namespaces {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+	return prefixes
+}
+
+// Declare returns the query with PREFIX declarations prepended for the
+// registered prefixes it uses but does not declare itself. Prefixed
+// names inside strings, IRIs and comments are ignored. Prefixes that
+// are not registered are left for the endpoint to resolve.
+func (registry *PrefixRegistry) Declare(query string) string {
+	used, declared := scanPrefixes(query)
+	var missing []string
+	for prefix := range used {
+		if declared[prefix] {
+			continue
+		}
+		if _, ok := registry.Namespace(prefix); ok {
+			missing = append(missing, prefix)
+		}
+	}
+	if len(missing) == 0 {
+		return query
+	}
+	sort.Strings(missing)
+	var declarations strings.Builder
+	for _, prefix := range missing {
+		namespace, _ := registry.Namespace(prefix)
+		fmt.Fprintf(&declarations, "PREFIX %s: <%s>\n", prefix, namespace)
+	}
+	return declarations.String() + query
+}
+
+// validPrefix reports whether a string is a valid prefix name.
+func validPrefix(prefix string) bool {
+	for i, char := range prefix {
+		if i == 0 && !unicode.IsLetter(char) {
+			return false
+		}
+		if !isNameChar(char) && char != '.' {
+			return false
+		}
+	}
+	return !strings.HasSuffix(prefix, ".")
+}
+
+// scanPrefixes returns the prefixes used in a query and the prefixes
+// it declares.
+func scanPrefixes(query string) (map[string]bool, map[string]bool) {
+	used := map[string]bool{}
+	declared := map[string]bool{}
+	for _, seg := range scanQuery(query) {
+		if seg.kind != segmentCode {
+			continue
+		}
+		for _, match := range prefixDeclaration.FindAllStringSubmatch(seg.text, -1) {
+			declared[match[1]] = true
+		}
+		runes := []rune(seg.text)
+		for pos := 0; pos < len(runes); pos++ {
+			if runes[pos] != ':' {
+				continue
+			}
+			start := pos
+			for start > 0 && (isNameChar(runes[start-1]) || runes[start-1] == '.') {
+				start--
+			}
+			prefix := strings.TrimLeft(string(runes[start:pos]), ".")
+			// A colon after a variable or language tag isn't part of a
+			// prefixed name, nor is the colon of a blank node label.
+			inName := start > 0 && strings.ContainsRune("?$@", runes[start-1])
+			if !inName && (prefix == "" || validPrefix(prefix)) {
+				used[prefix] = true
+			}
+			// Skip the local name, which may itself contain colons.
+			for pos+1 < len(runes) && (isNameChar(runes[pos+1]) || strings.ContainsRune(":.%\\", runes[pos+1])) {
+				if runes[pos+1] == '\\' {
+					pos++
+				}
+				pos++
+			}
+		}
+	}
+	return used, declared
+}
@@ -0,0 +1,81 @@
+package spargo
+
+import (
+	"testing"
+)
+
+// TestDeclarePrefixes makes sure only the prefixes a query uses, and
+// doesn't already declare, are added to it.
+func TestDeclarePrefixes(t *testing.T) {
+	query := `PREFIX wd: <http://example.com/not-wikidata/>
+# rdfs:label is only mentioned in a comment.
+SELECT ?item ?label WHERE {
+	?item wdt:P31/wdt:P279* wd:Q5 ;
+	      schema:description "owl:Thing"@en-gb ;
+	      <http://example.com/skos:note> _:b0 .
+	?item ex:label ?label .
+}`
+	expected := "PREFIX schema: <http://schema.org/>\n" +
+		"PREFIX wdt: <http://www.wikidata.org/prop/direct/>\n" + query
+	registry := NewPrefixRegistry()
+	if res := registry.Declare(query); res != expected {
+		t.Errorf("Unexpected query:\n%s", res)
+	}
+	if err := registry.Register("ex", "http://example.com/"); err != nil {
+		t.Fatalf("Unexpected error registering prefix: %s", err)
+	}
+	expected = "PREFIX ex: <http://example.com/>\n" + expected
+	if res := registry.Declare(query); res != expected {
+		t.Errorf("Unexpected query after registering ex:\n%s", res)
+	}
+	declared := registry.Declare(query)
+	if res := registry.Declare(declared); res != declared {
+		t.Errorf("Expected declaring prefixes to be idempotent:\n%s", res)
+	}
+}
+
+// TestRegisterPrefix checks prefix names and namespaces are validated.
+func TestRegisterPrefix(t *testing.T) {
+	registry := &PrefixRegistry{}
+	if err := registry.Register("", "http://example.com/#"); err != nil {
+		t.Errorf("Unexpected error registering the empty prefix: %s", err)
+	}
+	for _, prefix := range []string{"1ex", "ex.", "e x", "_"} {
+		if err := registry.Register(prefix, "http://example.com/"); err == nil {
+			t.Errorf("Expected an error registering prefix '%s'", prefix)
+		}
+	}
+	if err := registry.Register("ex", "http://example.com/> . }"); err == nil {
+		t.Errorf("Expected an error registering an invalid namespace")
+	}
+	if prefixes := registry.Prefixes(); len(prefixes) != 1 || prefixes[0] != "" {
+		t.Errorf("Unexpected prefixes registered: %v", prefixes)
+	}
+	if res := registry.Declare("ASK { :a :b ?c }"); res != "PREFIX : <http://example.com/#>\nASK { :a :b ?c }" {
+		t.Errorf("Unexpected query: %s", res)
+	}
+}
+
+// TestClientPrefixes makes sure the client declares prefixes when it
+// has a registry, and sends the query as it is otherwise.
+func TestClientPrefixes(t *testing.T) {
+	captured := capturedRequest{}
+	sparql := SPARQLClient{}
+	sparql.ClientInit("http://example.com/sparql", "SELECT ?item WHERE { ?item wdt:P31 wd:Q5 }")
+	sparql.Client = newCapturingClient(&captured, 200)
+	if _, err := sparql.SPARQLGo(); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if res := captured.url.Query().Get("query"); res != sparql.Query {
+		t.Errorf("Expected the query to be sent unchanged, received: %s", res)
+	}
+	sparql.SetPrefixes(NewPrefixRegistry())
+	if _, err := sparql.SPARQLGo(); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	expected := "PREFIX wd: <http://www.wikidata.org/entity/>\n" +
+		"PREFIX wdt: <http://www.wikidata.org/prop/direct/>\n" + sparql.Query
+	if res := captured.url.Query().Get("query"); res != expected {
+		t.Errorf("Unexpected query sent:\n%s", res)
+	}
+}
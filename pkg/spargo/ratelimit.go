@@ -0,0 +1,152 @@
+package spargo
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limiter controls how quickly requests are made to an endpoint.
+// Acquire blocks until the request may be sent, or until ctx ends, and
+// returns a function that must be called once the request completes.
+type Limiter interface {
+	Acquire(ctx context.Context, req *http.Request) (func(), error)
+}
+
+// RateLimiter limits requests to a number per second, with bursts, and
+// to a maximum number of requests in flight at once. A RateLimiter is
+// safe for concurrent use and can be shared between SPARQLClients.
+type RateLimiter struct {
+	interval time.Duration
+	burst    float64
+	inFlight chan struct{}
+
+	mutex  sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter returns a RateLimiter that allows perSecond requests
+// per second, bursts of up to burst requests, and at most maxInFlight
+// concurrent requests. A zero perSecond or maxInFlight disables that
+// limit. burst is treated as 1 if it is less than 1.
+func NewRateLimiter(perSecond float64, burst int, maxInFlight int) *RateLimiter {
+	limiter := &RateLimiter{}
+	if perSecond > 0 {
+		limiter.interval = time.Duration(float64(time.Second) / perSecond)
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	limiter.burst = float64(burst)
+	limiter.tokens = limiter.burst
+	if maxInFlight > 0 {
+		limiter.inFlight = make(chan struct{}, maxInFlight)
+	}
+	return limiter
+}
+
+// Acquire waits for a free slot and for the rate limit to allow
+// another request. The returned function releases the slot.
+func (limiter *RateLimiter) Acquire(ctx context.Context, req *http.Request) (func(), error) {
+	release := func() {}
+	if limiter.inFlight != nil {
+		select {
+		case limiter.inFlight <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		release = func() { <-limiter.inFlight }
+	}
+	if err := limiter.wait(ctx); err != nil {
+		release()
+		return nil, err
+	}
+	return release, nil
+}
+
+// wait blocks until a token is available in the bucket. Tokens are
+// taken as soon as they are reserved so that waiting callers queue
+// behind each other.
+func (limiter *RateLimiter) wait(ctx context.Context) error {
+	if limiter.interval == 0 {
+		return nil
+	}
+	limiter.mutex.Lock()
+	now := time.Now()
+	if !limiter.last.IsZero() {
+		limiter.tokens += float64(now.Sub(limiter.last)) / float64(limiter.interval)
+		if limiter.tokens > limiter.burst {
+			limiter.tokens = limiter.burst
+		}
+	}
+	limiter.last = now
+	limiter.tokens--
+	var delay time.Duration
+	if limiter.tokens < 0 {
+		delay = time.Duration(-limiter.tokens * float64(limiter.interval))
+	}
+	limiter.mutex.Unlock()
+
+	if delay == 0 {
+		return nil
+	}
+	if err := sleepContext(ctx, delay); err != nil {
+		// Hand back the token we didn't use.
+		limiter.mutex.Lock()
+		limiter.tokens++
+		limiter.mutex.Unlock()
+		return err
+	}
+	return nil
+}
+
+// HostLimiters shares rate limits between clients by endpoint host, so
+// that every client querying e.g. query.wikidata.org is subject to the
+// same limit. A limiter is created for each host on first use.
+type HostLimiters struct {
+	perSecond   float64
+	burst       int
+	maxInFlight int
+
+	mutex    sync.Mutex
+	limiters map[string]*RateLimiter
+}
+
+// NewHostLimiters returns a HostLimiters that creates a limiter with
+// the given settings, see NewRateLimiter, for each host it sees.
+func NewHostLimiters(perSecond float64, burst int, maxInFlight int) *HostLimiters {
+	return &HostLimiters{
+		perSecond:   perSecond,
+		burst:       burst,
+		maxInFlight: maxInFlight,
+		limiters:    make(map[string]*RateLimiter),
+	}
+}
+
+// Set overrides the limiter used for a host.
+func (hosts *HostLimiters) Set(host string, limiter *RateLimiter) {
+	hosts.mutex.Lock()
+	defer hosts.mutex.Unlock()
+	hosts.limiters[strings.ToLower(host)] = limiter
+}
+
+// Limiter returns the limiter for a host, creating it if needed.
+func (hosts *HostLimiters) Limiter(host string) *RateLimiter {
+	host = strings.ToLower(host)
+	hosts.mutex.Lock()
+	defer hosts.mutex.Unlock()
+	limiter, ok := hosts.limiters[host]
+	if !ok {
+		limiter = NewRateLimiter(hosts.perSecond, hosts.burst, hosts.maxInFlight)
+		hosts.limiters[host] = limiter
+	}
+	return limiter
+}
+
+// Acquire waits on the limiter for the host the request is sent to.
+func (hosts *HostLimiters) Acquire(ctx context.Context, req *http.Request) (func(), error) {
+	return hosts.Limiter(req.URL.Host).Acquire(ctx, req)
+}
@@ -0,0 +1,125 @@
+package spargo
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestRateLimiterRate makes sure requests beyond the burst are delayed
+// according to the requested rate.
+func TestRateLimiterRate(t *testing.T) {
+	waits, restore := mockSleep()
+	defer restore()
+
+	limiter := NewRateLimiter(10, 2, 0)
+	for i := 0; i < 4; i++ {
+		release, err := limiter.Acquire(context.Background(), nil)
+		if err != nil {
+			t.Fatalf("Unexpected error from Acquire: %s", err)
+		}
+		release()
+	}
+	if len(*waits) != 2 {
+		t.Fatalf("Expected the two requests beyond the burst to wait, received %v", *waits)
+	}
+	for i, expected := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond} {
+		wait := (*waits)[i]
+		if wait > expected || wait < expected-10*time.Millisecond {
+			t.Errorf("Expected a wait of around %v, received %v", expected, wait)
+		}
+	}
+}
+
+// TestRateLimiterInFlight makes sure no more than the maximum number of
+// queries are in flight at once from clients sharing a limiter.
+func TestRateLimiterInFlight(t *testing.T) {
+	var mutex sync.Mutex
+	inFlight, maxSeen := 0, 0
+
+	httpClient := NewTestClient(func(req *http.Request) *http.Response {
+		mutex.Lock()
+		inFlight++
+		if inFlight > maxSeen {
+			maxSeen = inFlight
+		}
+		mutex.Unlock()
+		time.Sleep(5 * time.Millisecond)
+		mutex.Lock()
+		inFlight--
+		mutex.Unlock()
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(bytes.NewBufferString(testEmptyResult)),
+			Header:     make(http.Header),
+		}
+	})
+
+	limiter := NewHostLimiters(0, 0, 2)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sparql := SPARQLClient{}
+			sparql.Client = httpClient
+			sparql.ClientInit("http://example.com", testQuery)
+			sparql.SetLimiter(limiter)
+			if _, err := sparql.SPARQLGo(); err != nil {
+				t.Errorf("Unexpected error from SPARQLGo: %s", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if maxSeen > 2 {
+		t.Errorf("Expected at most 2 queries in flight, saw %d", maxSeen)
+	}
+}
+
+// TestRateLimiterCancel makes sure a caller blocked on a limiter is
+// released when its context is cancelled.
+func TestRateLimiterCancel(t *testing.T) {
+	limiter := NewRateLimiter(0, 0, 1)
+	release, err := limiter.Acquire(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("Unexpected error from Acquire: %s", err)
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	sparql := SPARQLClient{}
+	sparql.Client = NewTestClient(func(req *http.Request) *http.Response {
+		t.Errorf("Request should not be sent while the limiter is full")
+		return nil
+	})
+	sparql.ClientInit("http://example.com", testQuery)
+	sparql.SetLimiter(limiter)
+
+	if _, err := sparql.SPARQLGoContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected a context.DeadlineExceeded error, received: %v", err)
+	}
+}
+
+// TestHostLimiters makes sure limiters are shared by host.
+func TestHostLimiters(t *testing.T) {
+	hosts := NewHostLimiters(1, 1, 1)
+	if hosts.Limiter("query.wikidata.org") != hosts.Limiter("Query.Wikidata.org") {
+		t.Errorf("Expected the same limiter for the same host")
+	}
+	if hosts.Limiter("query.wikidata.org") == hosts.Limiter("the-fr.org") {
+		t.Errorf("Expected different limiters for different hosts")
+	}
+	custom := NewRateLimiter(5, 1, 0)
+	hosts.Set("the-fr.org", custom)
+	if hosts.Limiter("the-fr.org") != custom {
+		t.Errorf("Expected the limiter set for the host to be used")
+	}
+}
@@ -0,0 +1,179 @@
+package spargo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Common datatype and vocabulary IRIs.
+const (
+	xsdNamespace   string = "http://www.w3.org/2001/XMLSchema#"
+	rdfNamespace   string = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+	XSDString      string = xsdNamespace + "string"
+	XSDBoolean     string = xsdNamespace + "boolean"
+	XSDInteger     string = xsdNamespace + "integer"
+	XSDDecimal     string = xsdNamespace + "decimal"
+	XSDDouble      string = xsdNamespace + "double"
+	XSDFloat       string = xsdNamespace + "float"
+	XSDDateTime    string = xsdNamespace + "dateTime"
+	XSDDate        string = xsdNamespace + "date"
+	RDFLangString  string = rdfNamespace + "langString"
+	rdfType        string = rdfNamespace + "type"
+	rdfFirst       string = rdfNamespace + "first"
+	rdfRest        string = rdfNamespace + "rest"
+	rdfNil         string = rdfNamespace + "nil"
+	blankNodeLabel string = "_:"
+)
+
+// Term is an RDF term: an IRI, a Literal or a BlankNode. String returns
+// the term in N-Triples syntax.
+type Term interface {
+	String() string
+	// Item returns the term as it would appear in a SPARQL result.
+	Item() Item
+}
+
+// IRI is an RDF term identifying a resource.
+type IRI string
+
+// String returns the IRI in N-Triples syntax.
+func (iri IRI) String() string {
+	return "<" + escapeIRI(string(iri)) + ">"
+}
+
+// Item returns the IRI as a SPARQL result Item.
+func (iri IRI) Item() Item {
+	return Item{Type: "uri", Value: string(iri)}
+}
+
+// BlankNode is an RDF term for a resource without an IRI. Its value is
+// the blank node's label, which is only meaningful within the graph it
+// is found in.
+type BlankNode string
+
+// String returns the blank node in N-Triples syntax.
+func (node BlankNode) String() string {
+	return blankNodeLabel + string(node)
+}
+
+// Item returns the blank node as a SPARQL result Item.
+func (node BlankNode) Item() Item {
+	return Item{Type: "bnode", Value: string(node)}
+}
+
+// Literal is an RDF term for a value such as a string, number or date.
+// A literal has either a language tag or a datatype, but not both. A
+// literal with neither is a simple xsd:string literal.
+type Literal struct {
+	Value    string
+	Lang     string
+	DataType string
+}
+
+// String returns the literal in N-Triples syntax.
+func (literal Literal) String() string {
+	quoted := `"` + escapeLiteral(literal.Value) + `"`
+	if literal.Lang != "" {
+		return quoted + "@" + literal.Lang
+	}
+	if literal.DataType != "" && literal.DataType != XSDString {
+		return quoted + "^^" + IRI(literal.DataType).String()
+	}
+	return quoted
+}
+
+// Item returns the literal as a SPARQL result Item.
+func (literal Literal) Item() Item {
+	return Item{
+		Type:     "literal",
+		Value:    literal.Value,
+		Lang:     literal.Lang,
+		DataType: literal.DataType,
+	}
+}
+
+// TermFromItem converts an Item from a SPARQL result to an RDF term.
+func TermFromItem(item Item) (Term, error) {
+	switch item.Type {
+	case "uri":
+		return IRI(item.Value), nil
+	case "bnode":
+		return BlankNode(item.Value), nil
+	case "literal", "typed-literal":
+		return Literal{Value: item.Value, Lang: item.Lang, DataType: item.DataType}, nil
+	}
+	return nil, fmt.Errorf("spargo: unknown term type: '%s'", item.Type)
+}
+
+// Triple is a single RDF statement.
+type Triple struct {
+	Subject   Term
+	Predicate Term
+	Object    Term
+}
+
+// String returns the triple as a line of N-Triples.
+func (triple Triple) String() string {
+	return fmt.Sprintf("%s %s %s .", triple.Subject, triple.Predicate, triple.Object)
+}
+
+// Graph is a set of RDF triples, e.g. the response to a CONSTRUCT or
+// DESCRIBE query.
+type Graph []Triple
+
+// String returns the graph as an N-Triples document.
+func (graph Graph) String() string {
+	var builder strings.Builder
+	for _, triple := range graph {
+		builder.WriteString(triple.String())
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
+
+// escapeLiteral escapes the characters that cannot appear unescaped in
+// a quoted N-Triples or SPARQL literal.
+func escapeLiteral(value string) string {
+	var builder strings.Builder
+	for _, char := range value {
+		switch char {
+		case '\\':
+			builder.WriteString(`\\`)
+		case '"':
+			builder.WriteString(`\"`)
+		case '\n':
+			builder.WriteString(`\n`)
+		case '\r':
+			builder.WriteString(`\r`)
+		case '\t':
+			builder.WriteString(`\t`)
+		case '\b':
+			builder.WriteString(`\b`)
+		case '\f':
+			builder.WriteString(`\f`)
+		default:
+			builder.WriteRune(char)
+		}
+	}
+	return builder.String()
+}
+
+// escapeIRI escapes the characters that cannot appear in an N-Triples
+// or SPARQL IRI reference.
+func escapeIRI(value string) string {
+	var builder strings.Builder
+	for _, char := range value {
+		if illegalIRIChar(char) {
+			fmt.Fprintf(&builder, `\u%04X`, char)
+			continue
+		}
+		builder.WriteRune(char)
+	}
+	return builder.String()
+}
+
+// illegalIRIChar reports whether a character is excluded from IRI
+// references by the N-Triples, Turtle and SPARQL grammars.
+func illegalIRIChar(char rune) bool {
+	return char <= 0x20 || strings.ContainsRune("<>\"{}|^`\\", char)
+}
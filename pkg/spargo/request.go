@@ -0,0 +1,123 @@
+package spargo
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// RequestMethod describes how a query is sent to a SPARQL endpoint. The
+// SPARQL 1.1 Protocol allows a query to be sent via GET, via POST with
+// URL-encoded parameters, or via POST directly in the request body.
+type RequestMethod int
+
+// Request methods supported by SPARQLClient.
+const (
+	// MethodAuto uses GET unless the encoded query is longer than the
+	// client's PostThreshold, in which case MethodPostForm is used.
+	MethodAuto RequestMethod = iota
+	// MethodGet sends the query in the URL of a GET request.
+	MethodGet
+	// MethodPostForm sends the query as an
+	// application/x-www-form-urlencoded POST body.
+	MethodPostForm
+	// MethodPostDirect sends the query unencoded as an
+	// application/sparql-query POST body.
+	MethodPostDirect
+)
+
+// DefaultPostThreshold is the length of the URL-encoded query above
+// which MethodAuto switches from GET to POST. It sits comfortably
+// below the 8KB limit that many servers and proxies place on URLs.
+const DefaultPostThreshold int = 2048
+
+// Content types used when sending a query in the body of a POST.
+const (
+	formContentType  string = "application/x-www-form-urlencoded"
+	queryContentType string = "application/sparql-query"
+)
+
+// String returns a human readable name for the request method.
+func (method RequestMethod) String() string {
+	switch method {
+	case MethodAuto:
+		return "auto"
+	case MethodGet:
+		return "GET"
+	case MethodPostForm:
+		return "POST (form)"
+	case MethodPostDirect:
+		return "POST (direct)"
+	}
+	return fmt.Sprintf("RequestMethod(%d)", int(method))
+}
+
+// resolveMethod decides which concrete method to use for a query of a
+// given encoded length.
+func (endpoint *SPARQLClient) resolveMethod(encodedLen int) RequestMethod {
+	if endpoint.Method != MethodAuto {
+		return endpoint.Method
+	}
+	threshold := endpoint.PostThreshold
+	if threshold <= 0 {
+		threshold = DefaultPostThreshold
+	}
+	if encodedLen > threshold {
+		return MethodPostForm
+	}
+	return MethodGet
+}
+
+// newRequest packages a query, and the dataset it is evaluated against,
+// as a http.Request using the method configured for the client and
+// associates it with ctx. accept lists the response formats we are able
+// to handle.
+func (endpoint *SPARQLClient) newRequest(ctx context.Context, queryString string, accept string, dataset Dataset) (*http.Request, error) {
+	if err := dataset.validate(); err != nil {
+		return nil, err
+	}
+	params := url.Values{}
+	params.Add("query", queryString)
+	dataset.addTo(params)
+	encoded := params.Encode()
+
+	var req *http.Request
+	var err error
+
+	switch method := endpoint.resolveMethod(len(encoded)); method {
+	case MethodGet:
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, endpoint.BaseURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		existing := req.URL.Query()
+		existing.Add("query", queryString)
+		dataset.addTo(existing)
+		req.URL.RawQuery = existing.Encode()
+	case MethodPostForm:
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, endpoint.BaseURL, strings.NewReader(encoded))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", formContentType)
+	case MethodPostDirect:
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, endpoint.BaseURL, strings.NewReader(queryString))
+		if err != nil {
+			return nil, err
+		}
+		// The dataset can only be sent in the URL.
+		existing := req.URL.Query()
+		dataset.addTo(existing)
+		req.URL.RawQuery = existing.Encode()
+		req.Header.Set("Content-Type", queryContentType)
+	default:
+		return nil, fmt.Errorf("spargo: unsupported request method: %s", method)
+	}
+
+	req.Header.Add("User-Agent", endpoint.Agent)
+	req.Header.Add("Accept", accept)
+
+	return req, nil
+}
@@ -0,0 +1,106 @@
+package spargo
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// capturedRequest records the parts of a request we want to inspect
+// once it has been sent by SPARQLGo.
+type capturedRequest struct {
+	method      string
+	contentType string
+	url         *url.URL
+	body        string
+}
+
+// newCapturingClient returns a test client that records the request it
+// receives and responds with the given status code and an empty but
+// valid SPARQL result.
+func newCapturingClient(captured *capturedRequest, statusCode int) *http.Client {
+	return NewTestClient(func(req *http.Request) *http.Response {
+		captured.method = req.Method
+		captured.contentType = req.Header.Get("Content-Type")
+		captured.url = req.URL
+		if req.Body != nil {
+			body, _ := ioutil.ReadAll(req.Body)
+			captured.body = string(body)
+		}
+		return &http.Response{
+			StatusCode: statusCode,
+			Body:       ioutil.NopCloser(bytes.NewBufferString(testEmptyResult)),
+			Header:     make(http.Header),
+		}
+	})
+}
+
+// methodTests describes a row of data for testing request methods.
+type methodTests struct {
+	method        RequestMethod
+	threshold     int
+	query         string
+	expectedVerb  string
+	expectedType  string
+	queryInURL    bool
+	queryInBody   string
+	encodedInBody bool
+}
+
+var longQuery = testQuery + strings.Repeat("# padding to exceed the threshold\n", 100)
+
+var methodResults = []methodTests{
+	methodTests{MethodAuto, 0, testQuery, "GET", "", true, "", false},
+	methodTests{MethodAuto, 0, longQuery, "POST", formContentType, false, longQuery, true},
+	methodTests{MethodAuto, 10, testQuery, "POST", formContentType, false, testQuery, true},
+	methodTests{MethodGet, 0, longQuery, "GET", "", true, "", false},
+	methodTests{MethodPostForm, 0, testQuery, "POST", formContentType, false, testQuery, true},
+	methodTests{MethodPostDirect, 0, testQuery, "POST", queryContentType, false, testQuery, false},
+}
+
+// TestRequestMethods makes sure that queries are packaged according to
+// the method requested by the caller, or according to the query length
+// when the method is selected automatically.
+func TestRequestMethods(t *testing.T) {
+	for _, val := range methodResults {
+		captured := capturedRequest{}
+		sparql := SPARQLClient{}
+		sparql.Client = newCapturingClient(&captured, 200)
+		sparql.ClientInit("http://example.com/sparql?format=json", val.query)
+		sparql.SetMethod(val.method)
+		sparql.SetPostThreshold(val.threshold)
+
+		if _, err := sparql.SPARQLGo(); err != nil {
+			t.Errorf("Unexpected error for method %s: %s", val.method, err)
+			continue
+		}
+
+		if captured.method != val.expectedVerb {
+			t.Errorf("Expected HTTP method %s for %s, received %s", val.expectedVerb, val.method, captured.method)
+		}
+		if captured.contentType != val.expectedType {
+			t.Errorf("Expected content type '%s' for %s, received '%s'", val.expectedType, val.method, captured.contentType)
+		}
+		if captured.url.Query().Get("format") != "json" {
+			t.Errorf("Parameters in the endpoint URL should be preserved for %s: %s", val.method, captured.url)
+		}
+		if (captured.url.Query().Get("query") == val.query) != val.queryInURL {
+			t.Errorf("Unexpected query placement in URL for %s: %s", val.method, captured.url)
+		}
+
+		body := captured.body
+		if val.encodedInBody {
+			form, err := url.ParseQuery(body)
+			if err != nil {
+				t.Errorf("Cannot parse form body for %s: %s", val.method, err)
+			}
+			body = form.Get("query")
+		}
+		if body != val.queryInBody {
+			t.Errorf("Unexpected body for %s, expected '%s', received '%s'", val.method, val.queryInBody, body)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package spargo
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Defaults used by RetryPolicy when a value is not configured.
+const (
+	DefaultRetryAttempts  int           = 3
+	DefaultInitialBackoff time.Duration = 500 * time.Millisecond
+	DefaultMaxBackoff     time.Duration = 30 * time.Second
+	DefaultBackoffFactor  float64       = 2
+)
+
+// DefaultRetryableStatus lists the HTTP status codes that are retried
+// if a RetryPolicy doesn't provide its own list. 429 is used by
+// Wikidata's query service when a client is being throttled.
+var DefaultRetryableStatus = []int{
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// RetryPolicy describes when, and how often, SPARQLClient will retry a
+// failed request. Retries are opt-in and are enabled by attaching a
+// policy to the client using SetRetryPolicy.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts made, including the
+	// first. DefaultRetryAttempts is used if it is zero.
+	MaxAttempts int
+	// InitialBackoff is the wait before the first retry. Subsequent
+	// waits grow by BackoffFactor up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	BackoffFactor  float64
+	// Jitter is the fraction, between 0 and 1, of each backoff that
+	// is randomized so that many clients don't retry in lock-step.
+	Jitter float64
+	// RetryableStatus lists the status codes that will be retried.
+	// DefaultRetryableStatus is used if it is nil.
+	RetryableStatus []int
+	// RetryNetworkErrors enables retries when no response is received
+	// from the server at all, e.g. a connection is refused or reset.
+	RetryNetworkErrors bool
+	// MaxRetryAfter caps the wait a server can ask for using a
+	// Retry-After header. If the server asks for longer, no further
+	// attempts are made. There is no cap if it is zero.
+	MaxRetryAfter time.Duration
+	// OnRetry, if set, is called before waiting to retry a request.
+	OnRetry func(RetryEvent)
+}
+
+// RetryEvent describes a failed attempt that is about to be retried.
+type RetryEvent struct {
+	// Attempt is the number of the attempt that failed, starting at 1.
+	Attempt int
+	// StatusCode is the status returned by the server, or 0 if no
+	// response was received.
+	StatusCode int
+	// Err is the error returned by the failed attempt.
+	Err error
+	// Wait is how long the client will wait before the next attempt.
+	Wait time.Duration
+	// RetryAfter is true if Wait was requested by the server.
+	RetryAfter bool
+}
+
+// sleepContext waits for d or until ctx ends. It is a variable so that
+// tests can observe waits without sleeping.
+var sleepContext = func(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// attempts returns the maximum number of attempts allowed by the
+// policy. A nil policy allows a single attempt.
+func (policy *RetryPolicy) attempts() int {
+	if policy == nil {
+		return 1
+	}
+	if policy.MaxAttempts <= 0 {
+		return DefaultRetryAttempts
+	}
+	return policy.MaxAttempts
+}
+
+// retryableStatus reports whether the policy retries a status code.
+func (policy *RetryPolicy) retryableStatus(code int) bool {
+	codes := policy.RetryableStatus
+	if codes == nil {
+		codes = DefaultRetryableStatus
+	}
+	for _, retryable := range codes {
+		if retryable == code {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns the wait before the retry following the given
+// attempt, with jitter applied.
+func (policy *RetryPolicy) backoff(attempt int) time.Duration {
+	initial := policy.InitialBackoff
+	if initial <= 0 {
+		initial = DefaultInitialBackoff
+	}
+	maximum := policy.MaxBackoff
+	if maximum <= 0 {
+		maximum = DefaultMaxBackoff
+	}
+	factor := policy.BackoffFactor
+	if factor < 1 {
+		factor = DefaultBackoffFactor
+	}
+	wait := float64(initial) * math.Pow(factor, float64(attempt-1))
+	if wait > float64(maximum) {
+		wait = float64(maximum)
+	}
+	if policy.Jitter > 0 {
+		jitter := math.Min(policy.Jitter, 1)
+		wait -= wait * jitter * rand.Float64()
+	}
+	return time.Duration(wait)
+}
+
+// parseRetryAfter interprets the value of a Retry-After header, which
+// may be given either as a number of seconds or as a HTTP-date. The
+// wait is relative to now.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	wait := date.Sub(now)
+	if wait < 0 {
+		wait = 0
+	}
+	return wait, true
+}
+
+// next decides whether a failed attempt should be retried and, if so,
+// how long to wait first. resp is the response received from the
+// server, if any.
+func (policy *RetryPolicy) next(attempt int, resp *http.Response, err error) (RetryEvent, bool) {
+	event := RetryEvent{Attempt: attempt, Err: err}
+	if attempt >= policy.attempts() {
+		return event, false
+	}
+	if resp == nil {
+		if !policy.RetryNetworkErrors {
+			return event, false
+		}
+		event.Wait = policy.backoff(attempt)
+		return event, true
+	}
+	event.StatusCode = resp.StatusCode
+	if !policy.retryableStatus(resp.StatusCode) {
+		return event, false
+	}
+	if wait, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+		if policy.MaxRetryAfter > 0 && wait > policy.MaxRetryAfter {
+			return event, false
+		}
+		event.Wait = wait
+		event.RetryAfter = true
+		return event, true
+	}
+	event.Wait = policy.backoff(attempt)
+	return event, true
+}
@@ -0,0 +1,202 @@
+package spargo
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// mockSleep replaces sleepContext so that waits can be recorded
+// without slowing the tests down. The returned function restores it.
+func mockSleep() (*[]time.Duration, func()) {
+	waits := []time.Duration{}
+	original := sleepContext
+	sleepContext = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return ctx.Err()
+	}
+	return &waits, func() { sleepContext = original }
+}
+
+// newSequenceClient returns a test client that responds with each of
+// the given status codes in turn, followed by a valid result.
+func newSequenceClient(codes []int, header http.Header, calls *int) *http.Client {
+	return NewTestClient(func(req *http.Request) *http.Response {
+		*calls++
+		if *calls <= len(codes) {
+			return &http.Response{
+				StatusCode: codes[*calls-1],
+				Body:       ioutil.NopCloser(bytes.NewBufferString("")),
+				Header:     header,
+			}
+		}
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(bytes.NewBufferString(testString)),
+			Header:     make(http.Header),
+		}
+	})
+}
+
+// TestRetryBackoff makes sure retryable status codes are retried with
+// an exponential backoff and that each retry is reported to the caller.
+func TestRetryBackoff(t *testing.T) {
+	waits, restore := mockSleep()
+	defer restore()
+	calls := 0
+	events := []RetryEvent{}
+
+	sparql := SPARQLClient{}
+	sparql.Client = newSequenceClient([]int{503, 502}, make(http.Header), &calls)
+	sparql.ClientInit("http://example.com", testQuery)
+	sparql.SetRetryPolicy(&RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Second,
+		OnRetry:        func(event RetryEvent) { events = append(events, event) },
+	})
+
+	res, err := sparql.SPARQLGo()
+	if err != nil {
+		t.Fatalf("Expected the query to succeed after retries, received: %s", err)
+	}
+	if len(res.Results.Bindings) != 2 {
+		t.Errorf("Expected 2 results after retries, received %d", len(res.Results.Bindings))
+	}
+	if calls != 3 {
+		t.Errorf("Expected 3 attempts, received %d", calls)
+	}
+	expected := []time.Duration{time.Second, 2 * time.Second}
+	if len(*waits) != len(expected) || (*waits)[0] != expected[0] || (*waits)[1] != expected[1] {
+		t.Errorf("Expected waits of %v, received %v", expected, *waits)
+	}
+	if len(events) != 2 || events[0].StatusCode != 503 || events[1].Attempt != 2 {
+		t.Errorf("Retries were not reported as expected: %+v", events)
+	}
+}
+
+// TestRetryExhausted makes sure the final error is returned once the
+// policy runs out of attempts, and that non-retryable codes are not
+// retried at all.
+func TestRetryExhausted(t *testing.T) {
+	_, restore := mockSleep()
+	defer restore()
+	for _, code := range []int{429, 400} {
+		calls := 0
+		sparql := SPARQLClient{}
+		sparql.Client = newSequenceClient([]int{code, code, code}, make(http.Header), &calls)
+		sparql.ClientInit("http://example.com", testQuery)
+		sparql.SetRetryPolicy(&RetryPolicy{MaxAttempts: 2})
+
+		_, err := sparql.SPARQLGo()
+		responseTest := ResponseError{}
+		if !errors.As(err, &responseTest) {
+			t.Errorf("Expected a ResponseError for %d, received: %v", code, err)
+		}
+		expected := 2
+		if code == 400 {
+			expected = 1
+		}
+		if calls != expected {
+			t.Errorf("Expected %d attempts for status %d, received %d", expected, code, calls)
+		}
+	}
+}
+
+// TestRetryAfterHeader makes sure the wait requested by the server is
+// used in place of the policy's own backoff.
+func TestRetryAfterHeader(t *testing.T) {
+	waits, restore := mockSleep()
+	defer restore()
+	calls := 0
+	header := make(http.Header)
+	header.Set("Retry-After", "7")
+
+	sparql := SPARQLClient{}
+	sparql.Client = newSequenceClient([]int{429}, header, &calls)
+	sparql.ClientInit("http://example.com", testQuery)
+	sparql.SetRetryPolicy(&RetryPolicy{})
+
+	if _, err := sparql.SPARQLGo(); err != nil {
+		t.Fatalf("Expected the query to succeed after retries, received: %s", err)
+	}
+	if len(*waits) != 1 || (*waits)[0] != 7*time.Second {
+		t.Errorf("Expected a single wait of 7s, received %v", *waits)
+	}
+
+	calls = 0
+	sparql.Retry.MaxRetryAfter = time.Second
+	if _, err := sparql.SPARQLGo(); err == nil {
+		t.Errorf("Expected an error when Retry-After exceeds MaxRetryAfter")
+	}
+	if calls != 1 {
+		t.Errorf("Expected a single attempt when Retry-After exceeds MaxRetryAfter, received %d", calls)
+	}
+}
+
+// TestRetryNetworkErrors makes sure network errors are only retried
+// when the policy asks for it.
+func TestRetryNetworkErrors(t *testing.T) {
+	_, restore := mockSleep()
+	defer restore()
+	for _, retryNetwork := range []bool{false, true} {
+		calls := 0
+		sparql := SPARQLClient{}
+		sparql.Client = NewTestClientError(func(req *http.Request) *http.Response {
+			calls++
+			return nil
+		})
+		sparql.ClientInit("http://example.com", testQuery)
+		sparql.SetRetryPolicy(&RetryPolicy{MaxAttempts: 3, RetryNetworkErrors: retryNetwork})
+
+		if _, err := sparql.SPARQLGo(); err == nil {
+			t.Errorf("Expected an error from SPARQLGo")
+		}
+		expected := 1
+		if retryNetwork {
+			expected = 3
+		}
+		if calls != expected {
+			t.Errorf("Expected %d attempts with RetryNetworkErrors %t, received %d", expected, retryNetwork, calls)
+		}
+	}
+}
+
+// TestParseRetryAfter checks both forms of the Retry-After header.
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value    string
+		expected time.Duration
+		ok       bool
+	}{
+		{"120", 2 * time.Minute, true},
+		{" 0 ", 0, true},
+		{"Mon, 01 Mar 2021 12:00:30 GMT", 30 * time.Second, true},
+		{"Mon, 01 Mar 2021 11:00:00 GMT", 0, true},
+		{"-5", 0, false},
+		{"soon", 0, false},
+		{"", 0, false},
+	}
+	for _, test := range tests {
+		wait, ok := parseRetryAfter(test.value, now)
+		if wait != test.expected || ok != test.ok {
+			t.Errorf("parseRetryAfter(%q) = %v, %t; expected %v, %t", test.value, wait, ok, test.expected, test.ok)
+		}
+	}
+}
+
+// TestBackoffJitter makes sure jitter never extends a backoff and that
+// backoffs are capped.
+func TestBackoffJitter(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 4 * time.Second, Jitter: 0.5}
+	for attempt := 1; attempt < 10; attempt++ {
+		wait := policy.backoff(attempt)
+		if wait > 4*time.Second || wait < 500*time.Millisecond {
+			t.Errorf("Backoff for attempt %d out of range: %v", attempt, wait)
+		}
+	}
+}
@@ -8,9 +8,13 @@ Package spargo enables the querying of a SPARQL data store using Golang.
 package spargo
 
 import (
-	"encoding/json"
+	"context"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
+	"sync"
+	"time"
 )
 
 // DefaultAgent user-agent determined by Wikidata User-agent policy: https://meta.wikimedia.org/wiki/User-Agent_policy.
@@ -19,6 +23,10 @@ const DefaultAgent string = "spargo/0.4.1 (https://github.com/ross-spencer/sparg
 // DefaultAccept is the default accept-content string to be used in the HTTP request header.
 const DefaultAccept string = "application/sparql-results+json, application/json"
 
+// DefaultGraphAccept is the default accept-content string used for CONSTRUCT
+// and DESCRIBE queries, which return RDF graphs rather than results.
+const DefaultGraphAccept string = "application/n-triples, text/turtle;q=0.9"
+
 // SPARQLClient ...
 type SPARQLClient struct {
 	Client  *http.Client
@@ -26,6 +34,47 @@ type SPARQLClient struct {
 	Agent   string
 	Accept  string
 	Query   string
+	// GraphAccept is the accept-content string used for queries that
+	// return RDF graphs.
+	GraphAccept string
+	// Method determines how the query is sent to the endpoint. The
+	// zero value, MethodAuto, switches from GET to POST once the
+	// encoded query is longer than PostThreshold.
+	Method RequestMethod
+	// PostThreshold is the encoded query length used by MethodAuto.
+	// DefaultPostThreshold is used if it is zero.
+	PostThreshold int
+	// Retry is an optional policy for retrying failed requests. No
+	// retries are made if it is nil. Updates are only retried if
+	// UpdateOptions.Retry is set.
+	Retry *RetryPolicy
+	// Limiter optionally limits the rate at which requests are made.
+	// It can be shared by clients, e.g. using HostLimiters.
+	Limiter Limiter
+	// UpdateURL is the endpoint SPARQL Update operations are sent to.
+	// BaseURL is used if it is empty.
+	UpdateURL string
+	// Prefixes, if set, supplies PREFIX declarations for the prefixed
+	// names a query or update uses without declaring, e.g. wd: and
+	// wdt: for queries written against Wikidata.
+	Prefixes *PrefixRegistry
+	// Cache, if set, holds responses to queries so that repeated
+	// queries needn't be sent to the endpoint. Updates and streamed
+	// results are never cached.
+	Cache Cache
+	// CacheTTL is how long responses are cached for. DefaultCacheTTL
+	// is used if it is zero.
+	CacheTTL time.Duration
+	// Auth, if set, adds credentials to every request, e.g. BasicAuth
+	// or BearerAuth.
+	Auth Authenticator
+	// Headers are added to every request, e.g. an API key required by
+	// a gateway in front of the endpoint.
+	Headers http.Header
+	// Dataset, if not empty, is sent with every query to choose the
+	// graphs it is evaluated against. WithDataset overrides it for a
+	// single query.
+	Dataset Dataset
 }
 
 // setupClient prepares a http client to talk to a SPARQL endpoint. If
@@ -40,49 +89,323 @@ func setupClient(endpoint *SPARQLClient) {
 // SPARQLGo takes our SparqlEndpoint structure and packages that as a request
 // for our SPARQL endpoint of choice. For the given
 func (endpoint *SPARQLClient) SPARQLGo() (SPARQLResult, error) {
+	return endpoint.SPARQLGoContext(context.Background())
+}
 
-	// Make sure there is a fresh http.Client{} associated with the
-	// structure for our request.
-	setupClient(endpoint)
+// SPARQLGoContext behaves like SPARQLGo but ties the request to ctx so
+// that it can be cancelled, or given a deadline, by the caller. If the
+// context ends before a result is parsed the error returned wraps
+// ctx.Err() and can be tested with errors.Is.
+//
+// Options, e.g. WithCachePolicy, change how this query alone is made.
+func (endpoint *SPARQLClient) SPARQLGoContext(ctx context.Context, opts ...QueryOption) (SPARQLResult, error) {
+	var res SPARQLResult
+	decode := func(header http.Header, body []byte) (err error) {
+		res, err = decodeResults(header, body)
+		return err
+	}
+	meta, err := endpoint.fetchQuery(ctx, endpoint.Query, endpoint.Accept, applyOptions(opts), decode)
+	if err != nil {
+		return SPARQLResult{}, err
+	}
+	res.Meta = meta
+	return res, nil
+}
 
-	req, err := http.NewRequest("GET", endpoint.BaseURL, nil)
+// Ask sends an ASK query to the endpoint and returns its answer.
+func (endpoint *SPARQLClient) Ask() (bool, error) {
+	return endpoint.AskContext(context.Background())
+}
+
+// AskContext behaves like Ask but ties the request to ctx so that it
+// can be cancelled by the caller. ErrNotBoolean is returned if the
+// response does not contain a boolean result.
+func (endpoint *SPARQLClient) AskContext(ctx context.Context, opts ...QueryOption) (bool, error) {
+	answer, _, err := endpoint.AskMeta(ctx, opts...)
+	return answer, err
+}
 
+// AskMeta behaves like AskContext and also returns the metadata of the
+// response, e.g. whether it came from the client's cache.
+func (endpoint *SPARQLClient) AskMeta(ctx context.Context, opts ...QueryOption) (bool, ResultMeta, error) {
+	res, err := endpoint.SPARQLGoContext(ctx, opts...)
 	if err != nil {
-		return SPARQLResult{}, err
+		return false, ResultMeta{}, err
+	}
+	if !res.IsBoolean() {
+		return false, ResultMeta{}, ErrNotBoolean
 	}
+	return *res.Boolean, res.Meta, nil
+}
 
-	req.Header.Add("User-Agent", endpoint.Agent)
-	req.Header.Add("Accept", endpoint.Accept)
+// SPARQLGraph sends a CONSTRUCT or DESCRIBE query to the endpoint and
+// returns the RDF graph it responds with.
+func (endpoint *SPARQLClient) SPARQLGraph() (Graph, error) {
+	return endpoint.SPARQLGraphContext(context.Background())
+}
 
-	query := req.URL.Query()
-	query.Add("query", endpoint.Query)
-	req.URL.RawQuery = query.Encode()
+// SPARQLGraphContext behaves like SPARQLGraph but ties the request to
+// ctx so that it can be cancelled by the caller. The response is parsed
+// as N-Triples or Turtle according to its content type.
+func (endpoint *SPARQLClient) SPARQLGraphContext(ctx context.Context, opts ...QueryOption) (Graph, error) {
+	graph, _, err := endpoint.SPARQLGraphMeta(ctx, opts...)
+	return graph, err
+}
 
-	resp, err := endpoint.Client.Do(req)
+// SPARQLGraphMeta behaves like SPARQLGraphContext and also returns the
+// metadata of the response, e.g. whether it came from the client's
+// cache.
+func (endpoint *SPARQLClient) SPARQLGraphMeta(ctx context.Context, opts ...QueryOption) (Graph, ResultMeta, error) {
+	accept := endpoint.GraphAccept
+	if accept == "" {
+		accept = DefaultGraphAccept
+	}
+	var graph Graph
+	decode := func(header http.Header, body []byte) (err error) {
+		graph, err = decodeGraph(header, body)
+		return err
+	}
+	meta, err := endpoint.fetchQuery(ctx, endpoint.Query, accept, applyOptions(opts), decode)
 	if err != nil {
-		return SPARQLResult{}, err
+		return nil, ResultMeta{}, err
 	}
-	defer resp.Body.Close()
+	return graph, meta, nil
+}
+
+// requestBuilder creates the request for a single attempt at talking
+// to the endpoint. A fresh request is needed for each attempt as the
+// body of a request can only be read once.
+type requestBuilder func(ctx context.Context) (*http.Request, error)
 
-	if resp.StatusCode != 200 {
-		responseErr := ResponseError{}
-		return SPARQLResult{}, responseErr.makeError(200, resp.StatusCode)
+// QueryOption changes how a single query is made, overriding the
+// client's settings for that query only.
+type QueryOption func(options *queryOptions)
 
+// queryOptions are the settings that can be changed for a single
+// query.
+type queryOptions struct {
+	cachePolicy CachePolicy
+	// dataset is nil unless the client's dataset is overridden.
+	dataset *Dataset
+}
+
+// applyOptions collects the options given with a query.
+func applyOptions(opts []QueryOption) queryOptions {
+	var options queryOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// fetchQuery sends a query to the endpoint, asking for a response in
+// one of the formats listed in accept, and passes the body and headers
+// of a successful response to decode. If the client has a cache the
+// response is taken from it when possible, and stored in it otherwise
+// once it has been decoded.
+func (endpoint *SPARQLClient) fetchQuery(ctx context.Context, queryString string, accept string, options queryOptions, decode func(http.Header, []byte) error) (ResultMeta, error) {
+	queryString = endpoint.declarePrefixes(queryString)
+	policy := options.cachePolicy
+	dataset := endpoint.dataset(options)
+	useCache := endpoint.Cache != nil && policy != CacheBypass
+	// The last request built is the one that was sent, and its headers
+	// are compared with those it had when it was built to find the
+	// credentials it was sent with.
+	var built *http.Request
+	var unauthenticated http.Header
+	build := func(ctx context.Context) (*http.Request, error) {
+		req, err := endpoint.newRequest(ctx, queryString, accept, dataset)
+		if err == nil {
+			built, unauthenticated = req, req.Header.Clone()
+		}
+		return req, err
+	}
+	if useCache && policy != CacheRefresh {
+		// Look for a response received with the credentials a request
+		// would be sent with now, without sending it.
+		probe, err := build(ctx)
+		if err != nil {
+			return ResultMeta{}, err
+		}
+		if err := endpoint.authenticate(probe); err != nil {
+			return ResultMeta{}, contextError(ctx, err)
+		}
+		key := endpoint.cacheKey(queryString, accept, dataset, credentials(unauthenticated, probe.Header))
+		if entry, ok := endpoint.Cache.Get(key); ok {
+			if err := decode(entry.Header, entry.Body); err == nil {
+				return ResultMeta{Cached: true, Stored: entry.Stored, Expires: entry.Expires}, nil
+			}
+		}
+	}
+	body, header, err := endpoint.fetch(ctx, build, statusOK)
+	if err != nil {
+		return ResultMeta{}, err
+	}
+	if err := decode(header, body); err != nil {
+		return ResultMeta{}, err
+	}
+	if useCache {
+		// The credentials may have been refreshed after being rejected,
+		// so the response is stored under those actually sent.
+		key := endpoint.cacheKey(queryString, accept, dataset, credentials(unauthenticated, built.Header))
+		now := cacheNow()
+		entry := CacheEntry{Body: body, Header: header, Stored: now, Expires: now.Add(endpoint.cacheTTL())}
+		// A cache that cannot be written to shouldn't fail a query
+		// that has succeeded, the response is simply fetched again
+		// next time.
+		_ = endpoint.Cache.Set(key, entry)
+	}
+	return ResultMeta{}, nil
+}
+
+// declarePrefixes adds any missing PREFIX declarations from the
+// client's prefix registry to a query or update.
+func (endpoint *SPARQLClient) declarePrefixes(queryString string) string {
+	if endpoint.Prefixes == nil {
+		return queryString
 	}
+	return endpoint.Prefixes.Declare(queryString)
+}
+
+// statusOK reports whether a status code is a successful response to
+// a query.
+func statusOK(code int) bool {
+	return code == http.StatusOK
+}
+
+// fetch sends the request created by build to the endpoint and returns
+// the body and headers of the response if success accepts its status
+// code.
+func (endpoint *SPARQLClient) fetch(ctx context.Context, build requestBuilder, success func(int) bool) ([]byte, http.Header, error) {
+	resp, err := endpoint.open(ctx, build, success)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
 
 	body, err := ioutil.ReadAll(resp.Body)
 
 	if err != nil {
-		return SPARQLResult{}, err
+		return nil, nil, contextError(ctx, err)
+	}
+
+	// The body may have been read in full just as the context ended,
+	// in which case we don't want to hand back a result.
+	if ctx.Err() != nil {
+		return nil, nil, contextError(ctx, ctx.Err())
+	}
+
+	return body, resp.Header, nil
+}
+
+// open sends the request created by build to the endpoint and returns
+// the response, with its body unread, if success accepts its status
+// code. The caller must close the body. If the client has a retry
+// policy, failed attempts are retried according to it.
+func (endpoint *SPARQLClient) open(ctx context.Context, build requestBuilder, success func(int) bool) (*http.Response, error) {
+
+	// Make sure there is a fresh http.Client{} associated with the
+	// structure for our request.
+	setupClient(endpoint)
+
+	refreshed := false
+	for attempt := 1; ; attempt++ {
+		req, err := build(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if err := endpoint.authenticate(req); err != nil {
+			return nil, contextError(ctx, err)
+		}
+		release, err := endpoint.acquire(ctx, req)
+		if err != nil {
+			return nil, contextError(ctx, err)
+		}
+		resp, err := endpoint.openOnce(ctx, req, success)
+		if err == nil {
+			// Hold on to the limiter until the body has been read.
+			resp.Body = &releasingBody{ReadCloser: resp.Body, release: release}
+			return resp, nil
+		}
+		release()
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		// Rejected credentials are renewed once, without counting
+		// towards the retry policy.
+		if !refreshed {
+			retry, refreshErr := endpoint.refreshAuth(ctx, resp)
+			if refreshErr != nil {
+				return nil, contextError(ctx, refreshErr)
+			}
+			if retry {
+				refreshed = true
+				attempt--
+				continue
+			}
+		}
+		event, retry := endpoint.Retry.next(attempt, resp, err)
+		if !retry {
+			return nil, err
+		}
+		if endpoint.Retry.OnRetry != nil {
+			endpoint.Retry.OnRetry(event)
+		}
+		if sleepErr := sleepContext(ctx, event.Wait); sleepErr != nil {
+			return nil, contextError(ctx, sleepErr)
+		}
+	}
+}
+
+// releasingBody releases a limiter when a response body is closed.
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+	once    sync.Once
+}
+
+// Close closes the body and releases the limiter.
+func (body *releasingBody) Close() error {
+	err := body.ReadCloser.Close()
+	body.once.Do(body.release)
+	return err
+}
+
+// acquire waits for the client's limiter, if any, to allow a request.
+func (endpoint *SPARQLClient) acquire(ctx context.Context, req *http.Request) (func(), error) {
+	if endpoint.Limiter == nil {
+		return func() {}, nil
 	}
+	return endpoint.Limiter.Acquire(ctx, req)
+}
 
-	var sparqlResponse SPARQLResult
-	err = json.Unmarshal(body, &sparqlResponse)
+// openOnce makes a single attempt at sending a request to the endpoint.
+// On failure the response is returned alongside the error, with its
+// body closed, so that the caller can inspect its status and headers.
+// It is nil if nothing was received.
+func (endpoint *SPARQLClient) openOnce(ctx context.Context, req *http.Request, success func(int) bool) (*http.Response, error) {
+	resp, err := endpoint.Client.Do(req)
 	if err != nil {
-		return SPARQLResult{}, err
+		return nil, contextError(ctx, err)
 	}
 
-	return sparqlResponse, nil
+	if !success(resp.StatusCode) {
+		responseErr := newResponseError(req, resp)
+		resp.Body.Close()
+		return resp, responseErr
+	}
+
+	return resp, nil
+}
+
+// contextError returns an error wrapping the context's own error if
+// the context has ended, so that callers can tell a cancelled or timed
+// out query apart from other failures. Otherwise err is returned.
+func contextError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return fmt.Errorf("spargo: query abandoned: %w", ctxErr)
+	}
+	return err
 }
 
 // SetUserAgent agent allows the user to set a custom user agent or use the
@@ -95,7 +418,8 @@ func (endpoint *SPARQLClient) SetUserAgent(agent string) {
 }
 
 // SetAcceptHeader will allow us to request results in other data formats. Our
-// default is SPARQL JSON.
+// default is SPARQL JSON. Responses are decoded according to their
+// Content-Type, so e.g. application/sparql-results+xml can be requested.
 func (endpoint *SPARQLClient) SetAcceptHeader(accept string) {
 	if accept == "" {
 		accept = DefaultAccept
@@ -103,6 +427,15 @@ func (endpoint *SPARQLClient) SetAcceptHeader(accept string) {
 	endpoint.Accept = accept
 }
 
+// SetGraphAcceptHeader will allow us to request graphs in other data
+// formats. Our default prefers N-Triples, and then Turtle.
+func (endpoint *SPARQLClient) SetGraphAcceptHeader(accept string) {
+	if accept == "" {
+		accept = DefaultGraphAccept
+	}
+	endpoint.GraphAccept = accept
+}
+
 // SetQuery enables us to set the SPARQL query.
 func (endpoint *SPARQLClient) SetQuery(queryString string) {
 	if queryString == "" {
@@ -111,11 +444,96 @@ func (endpoint *SPARQLClient) SetQuery(queryString string) {
 	endpoint.Query = queryString
 }
 
+// SetMethod lets us force the request method used to send a query, or
+// return to automatic selection with MethodAuto.
+func (endpoint *SPARQLClient) SetMethod(method RequestMethod) {
+	endpoint.Method = method
+}
+
+// SetPostThreshold sets the encoded query length above which
+// MethodAuto will send a query via POST instead of GET.
+func (endpoint *SPARQLClient) SetPostThreshold(threshold int) {
+	endpoint.PostThreshold = threshold
+}
+
+// SetRetryPolicy attaches a retry policy to the client. Passing nil
+// disables retries.
+func (endpoint *SPARQLClient) SetRetryPolicy(policy *RetryPolicy) {
+	endpoint.Retry = policy
+}
+
+// SetLimiter attaches a rate limiter to the client. Passing nil removes
+// any limit.
+func (endpoint *SPARQLClient) SetLimiter(limiter Limiter) {
+	endpoint.Limiter = limiter
+}
+
+// SetParameterizedQuery sets the SPARQL query from a query template and
+// the values bound to it.
+func (endpoint *SPARQLClient) SetParameterizedQuery(pq *ParameterizedQuery) {
+	endpoint.SetQuery(pq.String())
+}
+
+// SetPrefixes attaches a prefix registry to the client so that
+// undeclared prefixes are declared before a query is sent. Passing nil
+// sends queries as they are.
+func (endpoint *SPARQLClient) SetPrefixes(registry *PrefixRegistry) {
+	endpoint.Prefixes = registry
+}
+
+// SetCache attaches a cache to the client, with responses cached for
+// ttl, or DefaultCacheTTL if ttl is zero. Passing a nil cache disables
+// caching. WithCachePolicy can be used to bypass or refresh the cache
+// for individual queries.
+func (endpoint *SPARQLClient) SetCache(cache Cache, ttl time.Duration) {
+	endpoint.Cache = cache
+	endpoint.CacheTTL = ttl
+}
+
+// SetBasicAuth authenticates requests using HTTP Basic authentication.
+func (endpoint *SPARQLClient) SetBasicAuth(username string, password string) {
+	endpoint.Auth = BasicAuth{Username: username, Password: password}
+}
+
+// SetBearerToken authenticates requests using a bearer token. If
+// refresh isn't nil it is called for a new token when the endpoint
+// rejects the current one.
+func (endpoint *SPARQLClient) SetBearerToken(token string, refresh TokenSource) {
+	endpoint.Auth = NewBearerAuth(token, refresh)
+}
+
+// SetAuth attaches an Authenticator to the client. Passing nil sends
+// requests without credentials.
+func (endpoint *SPARQLClient) SetAuth(auth Authenticator) {
+	endpoint.Auth = auth
+}
+
+// SetHeader adds a header that is sent with every request, replacing
+// any value it already has.
+func (endpoint *SPARQLClient) SetHeader(name string, value string) {
+	if endpoint.Headers == nil {
+		endpoint.Headers = make(http.Header)
+	}
+	endpoint.Headers.Set(name, value)
+}
+
+// SetDataset sets the default and named graphs queries are evaluated
+// against. Passing empty slices returns to the endpoint's own dataset.
+func (endpoint *SPARQLClient) SetDataset(defaultGraphURIs []string, namedGraphURIs []string) {
+	endpoint.Dataset = Dataset{DefaultGraphURIs: defaultGraphURIs, NamedGraphURIs: namedGraphURIs}
+}
+
 // SetURL lets us set the URL of the SPARQL endpoint to query.
 func (endpoint *SPARQLClient) SetURL(url string) {
 	endpoint.BaseURL = url
 }
 
+// SetUpdateURL lets us set the URL of the SPARQL Update endpoint, which
+// for many stores, e.g. Fuseki, differs from the query endpoint.
+func (endpoint *SPARQLClient) SetUpdateURL(url string) {
+	endpoint.UpdateURL = url
+}
+
 // ClientInit provides us with a helper function to set endpoint URL and
 // query string in a single go. Default values are set for user-agent
 // and accept-content strings.
@@ -127,4 +545,5 @@ func (endpoint *SPARQLClient) ClientInit(url string, queryString string) {
 	// before calling SPARQLGo().
 	endpoint.SetUserAgent("")
 	endpoint.SetAcceptHeader("")
+	endpoint.SetGraphAcceptHeader("")
 }
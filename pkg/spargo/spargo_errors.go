@@ -1,26 +1,164 @@
 package spargo
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
 )
 
+// ErrNotBoolean is returned by Ask when the endpoint's response is not
+// a boolean result, e.g. the query was not an ASK query.
+var ErrNotBoolean = errors.New("spargo: response is not a boolean result")
+
+// ErrDatatypeMismatch is returned by the typed accessors on Item when the
+// item's datatype cannot be converted to the type requested.
+var ErrDatatypeMismatch = errors.New("spargo: datatype mismatch")
+
+// ErrUnboundVariable is returned by Unmarshal when a variable needed by
+// a required field is not bound in a row of results.
+var ErrUnboundVariable = errors.New("spargo: variable is not bound")
+
+// ErrNotPaginable is returned by SPARQLPaginate when the query is not a
+// SELECT query ordered by ORDER BY, without its own LIMIT or OFFSET.
+var ErrNotPaginable = errors.New("spargo: query cannot be paginated")
+
+// ErrMalformedQuery matches a ResponseError reporting that the endpoint
+// could not parse the query.
+var ErrMalformedQuery = errors.New("spargo: malformed query")
+
+// ErrTimeout matches a ResponseError reporting that the query ran for
+// longer than the endpoint allows, e.g. Wikidata's 60 second limit.
+var ErrTimeout = errors.New("spargo: query timed out on server")
+
+// ErrThrottled matches a ResponseError reporting that the endpoint is
+// limiting the rate of requests.
+var ErrThrottled = errors.New("spargo: request throttled by server")
+
+// errorBodyLimit is the most of an error response's body kept in a
+// ResponseError.
+const errorBodyLimit int64 = 64 * 1024
+
 // ResponseError defines an error type that can be inspected by callers
-// of spargo.
+// of spargo. It can be matched against ErrMalformedQuery, ErrTimeout
+// and ErrThrottled using errors.Is.
 type ResponseError struct {
-	expectedCode int
-	receivedCode int
-	Err          error
+	// StatusCode and Status are the status of the response.
+	StatusCode int
+	Status     string
+	// Header holds the headers of the response.
+	Header http.Header
+	// Body holds the start of the response body, up to 64KB. Truncated
+	// is true if the body was longer.
+	Body      []byte
+	Truncated bool
+	// Message is the error reported by the server, if it could be
+	// found in the body, e.g. a MalformedQueryException from
+	// Blazegraph with the line and column of the problem.
+	Message string
+	// Method and Endpoint describe the request that failed. Any
+	// password in the endpoint's URL is redacted.
+	Method   string
+	Endpoint string
+	Err      error
 }
 
-// makeError is an internal function that allows spargo to construct
-// meaningful errors and return that error to the caller.
-func (err ResponseError) makeError(expectedCode int, receivedCode int) error {
-	err.expectedCode = expectedCode
-	err.receivedCode = receivedCode
+// Patterns used to find the error message in the body of a response.
+var (
+	exceptionPattern  = regexp.MustCompile(`[A-Za-z_$][\w$]*(?:Exception|Error)(?:: |$)`)
+	statusLinePattern = regexp.MustCompile(`^(?i:error) \d{3}: `)
+)
+
+// newResponseError describes a failed response. The body is read, up to
+// errorBodyLimit, but not closed.
+func newResponseError(req *http.Request, resp *http.Response) ResponseError {
+	err := ResponseError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Header:     resp.Header,
+	}
+	if req != nil {
+		endpoint := *req.URL
+		endpoint.RawQuery = ""
+		err.Method = req.Method
+		err.Endpoint = redactURL(endpoint.String())
+	}
+	if resp.Body != nil {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, errorBodyLimit+1))
+		if int64(len(body)) > errorBodyLimit {
+			body = body[:errorBodyLimit]
+			err.Truncated = true
+		}
+		err.Body = body
+	}
+	err.Message = errorMessage(resp.Header, err.Body)
 	return err
 }
 
+// errorMessage finds the error reported by a server in the body of its
+// response. JSON bodies are expected to have a message or error member.
+// Otherwise the innermost Java exception is used, as reported by e.g.
+// Blazegraph and RDF4J, or the first line of a plain text body.
+func errorMessage(header http.Header, body []byte) string {
+	if media := mediaType(header); media == "application/json" || strings.HasSuffix(media, "+json") {
+		var members map[string]interface{}
+		if json.Unmarshal(body, &members) == nil {
+			for _, key := range []string{"message", "error"} {
+				if message, ok := members[key].(string); ok && message != "" {
+					return message
+				}
+			}
+		}
+		return ""
+	}
+	text := string(body)
+	for _, line := range strings.Split(text, "\n") {
+		if matches := exceptionPattern.FindAllStringIndex(line, -1); matches != nil {
+			return strings.TrimSpace(line[matches[len(matches)-1][0]:])
+		}
+	}
+	if media := mediaType(header); media != "text/plain" && media != "" {
+		return ""
+	}
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return statusLinePattern.ReplaceAllString(line, "")
+		}
+	}
+	return ""
+}
+
 // Error enables ResponseError to implement the Errors interface.
 func (err ResponseError) Error() string {
-	return fmt.Sprintf("spargo: unexpected response from server: %d", err.receivedCode)
+	if err.Message != "" {
+		return fmt.Sprintf("spargo: unexpected response from server: %d: %s", err.StatusCode, err.Message)
+	}
+	return fmt.Sprintf("spargo: unexpected response from server: %d", err.StatusCode)
+}
+
+// Unwrap returns the underlying error, if any.
+func (err ResponseError) Unwrap() error {
+	return err.Err
+}
+
+// Is reports whether the response matches one of the sentinel errors
+// ErrMalformedQuery, ErrTimeout and ErrThrottled.
+func (err ResponseError) Is(target error) bool {
+	switch target {
+	case ErrMalformedQuery:
+		return err.StatusCode == http.StatusBadRequest ||
+			strings.Contains(err.Message, "MalformedQueryException") ||
+			strings.Contains(err.Message, "QueryParseException")
+	case ErrTimeout:
+		return err.StatusCode == http.StatusRequestTimeout ||
+			err.StatusCode == http.StatusGatewayTimeout ||
+			strings.Contains(err.Message, "TimeoutException")
+	case ErrThrottled:
+		return err.StatusCode == http.StatusTooManyRequests
+	}
+	return false
 }
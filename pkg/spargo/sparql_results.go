@@ -3,6 +3,7 @@ package spargo
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 )
 
 /*
@@ -58,10 +59,31 @@ type Binding struct {
 	Bindings []map[string]Item `json:"bindings"`
 }
 
-// SPARQLResult packages a SPARQL response from an endpoint.
+// SPARQLResult packages a SPARQL response from an endpoint. The response
+// to an ASK query has no Results, but a Boolean instead.
 type SPARQLResult struct {
 	Head    map[string]interface{} `json:"head"`
 	Results Binding                `json:"results"`
+	Boolean *bool                  `json:"boolean,omitempty"`
+	// Meta describes how the result was obtained. It isn't part of
+	// the response from the endpoint.
+	Meta ResultMeta `json:"-"`
+}
+
+// ResultMeta describes how the response to a query was obtained.
+type ResultMeta struct {
+	// Cached is true if the result was taken from the client's cache
+	// rather than the endpoint.
+	Cached bool
+	// Stored and Expires are when a cached result was received from
+	// the endpoint, and when it expires.
+	Stored  time.Time
+	Expires time.Time
+}
+
+// IsBoolean reports whether the result is the response to an ASK query.
+func (sparql SPARQLResult) IsBoolean() bool {
+	return sparql.Boolean != nil
 }
 
 // String will return a string representation of SPARQLResult.
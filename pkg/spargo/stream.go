@@ -0,0 +1,281 @@
+package spargo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// ResultIterator steps through the bindings of a SELECT query one at a
+// time as they are read from the endpoint, rather than reading the
+// whole response into memory. It is used as follows:
+//
+//	results, err := sparqlMe.SPARQLStream(ctx)
+//	...
+//	defer results.Close()
+//	for results.Next() {
+//		binding := results.Binding()
+//		...
+//	}
+//	if err := results.Err(); err != nil {
+//		...
+//	}
+//
+// Only the SPARQL 1.1 Query Results JSON Format can be streamed.
+type ResultIterator struct {
+	ctx       context.Context
+	body      io.ReadCloser
+	decoder   *json.Decoder
+	head      map[string]interface{}
+	vars      []string
+	boolean   *bool
+	streaming bool
+	binding   map[string]Item
+	err       error
+}
+
+// SPARQLStream sends the client's query to the endpoint and returns an
+// iterator over the bindings in the response. The iterator must be
+// closed by the caller. The variables in the result are available from
+// Vars as soon as the iterator is returned, provided the endpoint sends
+// the head of the result before the bindings, as is usual. Streamed
+// results are never cached, so WithCachePolicy has no effect.
+func (endpoint *SPARQLClient) SPARQLStream(ctx context.Context, opts ...QueryOption) (*ResultIterator, error) {
+	queryString := endpoint.declarePrefixes(endpoint.Query)
+	dataset := endpoint.dataset(applyOptions(opts))
+	build := func(ctx context.Context) (*http.Request, error) {
+		return endpoint.newRequest(ctx, queryString, jsonResultsMediaType, dataset)
+	}
+	resp, err := endpoint.open(ctx, build, statusOK)
+	if err != nil {
+		return nil, err
+	}
+	switch media := mediaType(resp.Header); media {
+	case jsonResultsMediaType, "application/json", "":
+	default:
+		resp.Body.Close()
+		return nil, UnsupportedMediaTypeError{MediaType: media}
+	}
+	return newResultIterator(ctx, resp.Body)
+}
+
+// NewResultIterator returns an iterator over JSON results read from r.
+// If r is an io.Closer it is closed when the iterator is closed.
+func NewResultIterator(reader io.Reader) (*ResultIterator, error) {
+	closer, ok := reader.(io.ReadCloser)
+	if !ok {
+		closer = ioutil.NopCloser(reader)
+	}
+	return newResultIterator(context.Background(), closer)
+}
+
+// newResultIterator reads the response up until the first binding.
+func newResultIterator(ctx context.Context, body io.ReadCloser) (*ResultIterator, error) {
+	iterator := &ResultIterator{
+		ctx:     ctx,
+		body:    body,
+		decoder: json.NewDecoder(body),
+	}
+	if err := iterator.expectDelim('{'); err != nil {
+		body.Close()
+		return nil, err
+	}
+	if err := iterator.readMembers(); err != nil {
+		body.Close()
+		return nil, err
+	}
+	return iterator, nil
+}
+
+// Vars returns the variables listed in the head of the result.
+func (iterator *ResultIterator) Vars() []string {
+	return iterator.vars
+}
+
+// Head returns the head of the result as it appears in SPARQLResult.
+func (iterator *ResultIterator) Head() map[string]interface{} {
+	return iterator.head
+}
+
+// Boolean returns the answer to an ASK query, or nil if the response
+// is not a boolean result. It is only complete once Next returns false.
+func (iterator *ResultIterator) Boolean() *bool {
+	return iterator.boolean
+}
+
+// Next advances the iterator to the next binding, returning false when
+// there are no more bindings or an error occurs.
+func (iterator *ResultIterator) Next() bool {
+	iterator.binding = nil
+	if iterator.err != nil || !iterator.streaming {
+		return false
+	}
+	if err := iterator.ctx.Err(); err != nil {
+		iterator.err = contextError(iterator.ctx, err)
+		return false
+	}
+	if iterator.decoder.More() {
+		var binding map[string]Item
+		if err := iterator.decoder.Decode(&binding); err != nil {
+			iterator.err = contextError(iterator.ctx, err)
+			return false
+		}
+		iterator.binding = binding
+		return true
+	}
+	// Finish reading the response so that anything after the
+	// bindings, including errors, is seen.
+	iterator.streaming = false
+	if err := iterator.expectDelim(']'); err != nil {
+		iterator.err = err
+		return false
+	}
+	if err := iterator.skipMembers(); err != nil {
+		iterator.err = err
+		return false
+	}
+	if err := iterator.readMembers(); err != nil {
+		iterator.err = err
+	}
+	return false
+}
+
+// Binding returns the current binding.
+func (iterator *ResultIterator) Binding() map[string]Item {
+	return iterator.binding
+}
+
+// Err returns the first error encountered by the iterator.
+func (iterator *ResultIterator) Err() error {
+	return iterator.err
+}
+
+// Close closes the response body. It is safe to call more than once.
+func (iterator *ResultIterator) Close() error {
+	if iterator.body == nil {
+		return nil
+	}
+	err := iterator.body.Close()
+	iterator.body = nil
+	return err
+}
+
+// readMembers reads members of the top-level object until the start of
+// the bindings array, or the end of the object, is reached.
+func (iterator *ResultIterator) readMembers() error {
+	for iterator.decoder.More() {
+		key, err := iterator.key()
+		if err != nil {
+			return err
+		}
+		switch key {
+		case "head":
+			if err := iterator.decoder.Decode(&iterator.head); err != nil {
+				return contextError(iterator.ctx, err)
+			}
+			iterator.vars = headVars(iterator.head)
+		case "boolean":
+			if err := iterator.decoder.Decode(&iterator.boolean); err != nil {
+				return contextError(iterator.ctx, err)
+			}
+		case "results":
+			found, err := iterator.findBindings()
+			if err != nil || found {
+				return err
+			}
+		default:
+			var skip json.RawMessage
+			if err := iterator.decoder.Decode(&skip); err != nil {
+				return contextError(iterator.ctx, err)
+			}
+		}
+	}
+	return iterator.expectDelim('}')
+}
+
+// findBindings reads the results object up to the start of the
+// bindings array, reporting whether it was found.
+func (iterator *ResultIterator) findBindings() (bool, error) {
+	if err := iterator.expectDelim('{'); err != nil {
+		return false, err
+	}
+	for iterator.decoder.More() {
+		key, err := iterator.key()
+		if err != nil {
+			return false, err
+		}
+		if key == "bindings" {
+			token, err := iterator.decoder.Token()
+			if err != nil {
+				return false, contextError(iterator.ctx, err)
+			}
+			if token == nil {
+				continue
+			}
+			if delim, ok := token.(json.Delim); !ok || delim != '[' {
+				return false, fmt.Errorf("spargo: expected bindings array, found: %v", token)
+			}
+			iterator.streaming = true
+			return true, nil
+		}
+		var skip json.RawMessage
+		if err := iterator.decoder.Decode(&skip); err != nil {
+			return false, contextError(iterator.ctx, err)
+		}
+	}
+	return false, iterator.expectDelim('}')
+}
+
+// skipMembers skips what remains of the results object.
+func (iterator *ResultIterator) skipMembers() error {
+	for iterator.decoder.More() {
+		if _, err := iterator.key(); err != nil {
+			return err
+		}
+		var skip json.RawMessage
+		if err := iterator.decoder.Decode(&skip); err != nil {
+			return contextError(iterator.ctx, err)
+		}
+	}
+	return iterator.expectDelim('}')
+}
+
+// key reads the key of an object member.
+func (iterator *ResultIterator) key() (string, error) {
+	token, err := iterator.decoder.Token()
+	if err != nil {
+		return "", contextError(iterator.ctx, err)
+	}
+	key, ok := token.(string)
+	if !ok {
+		return "", fmt.Errorf("spargo: expected object key, found: %v", token)
+	}
+	return key, nil
+}
+
+// expectDelim reads the next token and makes sure it is delim.
+func (iterator *ResultIterator) expectDelim(delim json.Delim) error {
+	token, err := iterator.decoder.Token()
+	if err != nil {
+		return contextError(iterator.ctx, err)
+	}
+	if found, ok := token.(json.Delim); !ok || found != delim {
+		return fmt.Errorf("spargo: expected '%s' in results, found: %v", delim, token)
+	}
+	return nil
+}
+
+// headVars returns the variables listed in the head of a result.
+func headVars(head map[string]interface{}) []string {
+	list, _ := head["vars"].([]interface{})
+	vars := make([]string, 0, len(list))
+	for _, variable := range list {
+		if name, ok := variable.(string); ok {
+			vars = append(vars, name)
+		}
+	}
+	return vars
+}
@@ -0,0 +1,112 @@
+package spargo
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestSparqlStream makes sure bindings streamed from the endpoint match
+// those decoded in one go, and that the variables are available before
+// the first binding.
+func TestSparqlStream(t *testing.T) {
+	httpClient := NewTestClient(func(req *http.Request) *http.Response {
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(bytes.NewBufferString(testString)),
+			Header:     make(http.Header),
+		}
+	})
+
+	sparql := SPARQLClient{}
+	sparql.Client = httpClient
+	sparql.ClientInit("http://example.com", testQuery)
+
+	results, err := sparql.SPARQLStream(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error from SPARQLStream: %s", err)
+	}
+	defer results.Close()
+
+	if !reflect.DeepEqual(results.Vars(), []string{"format", "label"}) {
+		t.Errorf("Expected vars before the first binding, received: %v", results.Vars())
+	}
+
+	var streamed []map[string]Item
+	for results.Next() {
+		streamed = append(streamed, results.Binding())
+	}
+	if err := results.Err(); err != nil {
+		t.Fatalf("Unexpected error from iterator: %s", err)
+	}
+
+	expected, _ := DecodeJSONResults(strings.NewReader(testString))
+	if !reflect.DeepEqual(streamed, expected.Results.Bindings) {
+		t.Errorf("Streamed bindings differ from decoded bindings: %v", streamed)
+	}
+}
+
+// streamTests describes a row of data for testing the iterator with.
+type streamTests struct {
+	responseValue string
+	rows          int
+	expectErr     bool
+}
+
+var streamResults = []streamTests{
+	streamTests{testEmptyResult, 0, false},
+	streamTests{testAskTrue, 0, false},
+	streamTests{`{"results": {"distinct": false, "bindings": [{"a": {"type": "uri", "value": "http://example.com"}}]}, "head": {"vars": ["a"]}}`, 1, false},
+	streamTests{`{"head": {"vars": ["a"]}, "results": {"bindings": [{"a": {"type": "uri", "value": "http://example.com"}}, {"a": `, 1, true},
+	streamTests{`{"head": {"vars": ["a"]}, "results": {"bindings": {}}}`, 0, true},
+	streamTests{"Parsing should fail gracefully", 0, true},
+}
+
+// TestResultIterator makes sure unusual and broken responses are
+// handled by the iterator.
+func TestResultIterator(t *testing.T) {
+	for _, val := range streamResults {
+		results, err := NewResultIterator(strings.NewReader(val.responseValue))
+		rows := 0
+		if err == nil {
+			for results.Next() {
+				rows++
+			}
+			err = results.Err()
+			results.Close()
+		}
+		if (err != nil) != val.expectErr {
+			t.Errorf("Unexpected error state for '%s': %v", val.responseValue, err)
+		}
+		if rows != val.rows {
+			t.Errorf("Expected %d rows for '%s', received %d", val.rows, val.responseValue, rows)
+		}
+	}
+}
+
+// TestResultIteratorCancel makes sure iteration stops once the context
+// is cancelled.
+func TestResultIteratorCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	results, err := newResultIterator(ctx, ioutil.NopCloser(strings.NewReader(testString)))
+	if err != nil {
+		t.Fatalf("Unexpected error creating iterator: %s", err)
+	}
+	defer results.Close()
+
+	if !results.Next() {
+		t.Fatalf("Expected a binding before cancellation: %v", results.Err())
+	}
+	cancel()
+	if results.Next() {
+		t.Errorf("Expected iteration to stop once cancelled")
+	}
+	if !errors.Is(results.Err(), context.Canceled) {
+		t.Errorf("Expected a context.Canceled error, received: %v", results.Err())
+	}
+}
@@ -52,3 +52,37 @@ var testEmptyResult = `{
     "bindings": null
   }
 }`
+
+var testAskTrue = `{
+  "head": {},
+  "boolean": true
+}`
+
+var testAskFalse = `{
+  "head": {},
+  "boolean": false
+}`
+
+var testXMLString = `<?xml version="1.0"?>
+<sparql xmlns="http://www.w3.org/2005/sparql-results#">
+  <head>
+    <variable name="format"/>
+    <variable name="label"/>
+  </head>
+  <results>
+    <result>
+      <binding name="format"><uri>http://the-fr.org/id/file-format/25</uri></binding>
+      <binding name="label"><literal datatype="http://example.com/DataTypes#unicode" xml:lang="en">OS/2 Bitmap</literal></binding>
+    </result>
+    <result>
+      <binding name="format"><uri>http://the-fr.org/id/file-format/28</uri></binding>
+      <binding name="label"><literal datatype="http://example.com/DataTypes#unicode" xml:lang="en">CALS Compressed Bitmap</literal></binding>
+    </result>
+  </results>
+</sparql>`
+
+var testXMLAsk = `<?xml version="1.0"?>
+<sparql xmlns="http://www.w3.org/2005/sparql-results#">
+  <head/>
+  <boolean>true</boolean>
+</sparql>`
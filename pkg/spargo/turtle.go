@@ -0,0 +1,748 @@
+package spargo
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ParseTurtle reads a Turtle document and returns the triples it
+// describes. Relative IRIs are resolved against any @base given in the
+// document, and are otherwise left as they are.
+func ParseTurtle(reader io.Reader) (Graph, error) {
+	return parseRDF(reader, false)
+}
+
+// ParseNTriples reads an N-Triples document and returns its triples.
+func ParseNTriples(reader io.Reader) (Graph, error) {
+	return parseRDF(reader, true)
+}
+
+// parseRDF reads an entire document before handing it to the parser.
+func parseRDF(reader io.Reader, ntriples bool) (Graph, error) {
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	parser := turtleParser{
+		input:    []rune(string(data)),
+		ntriples: ntriples,
+		prefixes: make(map[string]string),
+	}
+	// N-Triples has no anonymous resources, so no labels are made up.
+	if !ntriples {
+		parser.labels = documentLabels(parser.input)
+	}
+	return parser.parse()
+}
+
+// turtleParser is a recursive descent parser for Turtle. N-Triples is
+// a subset of Turtle, and so is parsed by the same code with the Turtle
+// only syntax switched off.
+type turtleParser struct {
+	input    []rune
+	pos      int
+	ntriples bool
+	base     *url.URL
+	prefixes map[string]string
+	blanks   int
+	labels   map[string]bool
+	graph    Graph
+}
+
+// RDFSyntaxError describes a problem found parsing an RDF document.
+type RDFSyntaxError struct {
+	Line    int
+	Message string
+}
+
+// Error enables RDFSyntaxError to implement the Errors interface.
+func (err RDFSyntaxError) Error() string {
+	return fmt.Sprintf("spargo: rdf syntax error on line %d: %s", err.Line, err.Message)
+}
+
+// errorf returns an RDFSyntaxError for the parser's current position.
+func (parser *turtleParser) errorf(format string, args ...interface{}) error {
+	line := 1
+	for _, char := range parser.input[:parser.pos] {
+		if char == '\n' {
+			line++
+		}
+	}
+	return RDFSyntaxError{Line: line, Message: fmt.Sprintf(format, args...)}
+}
+
+// eof reports whether all of the input has been consumed.
+func (parser *turtleParser) eof() bool {
+	return parser.pos >= len(parser.input)
+}
+
+// peek returns the next character without consuming it, or 0 at the
+// end of the input.
+func (parser *turtleParser) peek() rune {
+	return parser.peekAt(0)
+}
+
+// peekAt returns the character offset characters ahead.
+func (parser *turtleParser) peekAt(offset int) rune {
+	if parser.pos+offset >= len(parser.input) {
+		return 0
+	}
+	return parser.input[parser.pos+offset]
+}
+
+// hasPrefix reports whether the remaining input starts with prefix.
+// Letters are compared case-insensitively if fold is set.
+func (parser *turtleParser) hasPrefix(prefix string, fold bool) bool {
+	runes := []rune(prefix)
+	if parser.pos+len(runes) > len(parser.input) {
+		return false
+	}
+	candidate := string(parser.input[parser.pos : parser.pos+len(runes)])
+	if fold {
+		return strings.EqualFold(candidate, prefix)
+	}
+	return candidate == prefix
+}
+
+// expect consumes the given character or returns an error.
+func (parser *turtleParser) expect(char rune) error {
+	parser.skipSpace()
+	if parser.peek() != char {
+		return parser.errorf("expected '%c', found %s", char, parser.describeNext())
+	}
+	parser.pos++
+	return nil
+}
+
+// describeNext describes the next character for use in errors.
+func (parser *turtleParser) describeNext() string {
+	if parser.eof() {
+		return "end of input"
+	}
+	return fmt.Sprintf("'%c'", parser.peek())
+}
+
+// skipSpace consumes whitespace and comments.
+func (parser *turtleParser) skipSpace() {
+	for !parser.eof() {
+		char := parser.peek()
+		if char == '#' {
+			for !parser.eof() && parser.peek() != '\n' {
+				parser.pos++
+			}
+			continue
+		}
+		if !unicode.IsSpace(char) {
+			return
+		}
+		parser.pos++
+	}
+}
+
+// parse consumes the document statement by statement.
+func (parser *turtleParser) parse() (Graph, error) {
+	for {
+		parser.skipSpace()
+		if parser.eof() {
+			return parser.graph, nil
+		}
+		if !parser.ntriples {
+			directive, err := parser.directive()
+			if err != nil {
+				return nil, err
+			}
+			if directive {
+				continue
+			}
+		}
+		if err := parser.triples(); err != nil {
+			return nil, err
+		}
+		if err := parser.expect('.'); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// directive parses @prefix and @base directives, and their SPARQL
+// style equivalents, reporting whether one was found.
+func (parser *turtleParser) directive() (bool, error) {
+	var sparqlStyle, isPrefix bool
+	switch {
+	case parser.hasPrefix("@prefix", false):
+		parser.pos += len("@prefix")
+		isPrefix = true
+	case parser.hasPrefix("@base", false):
+		parser.pos += len("@base")
+	case parser.hasPrefix("prefix", true) && unicode.IsSpace(parser.peekAt(len("prefix"))):
+		parser.pos += len("prefix")
+		sparqlStyle, isPrefix = true, true
+	case parser.hasPrefix("base", true) && unicode.IsSpace(parser.peekAt(len("base"))):
+		parser.pos += len("base")
+		sparqlStyle = true
+	default:
+		return false, nil
+	}
+
+	parser.skipSpace()
+	prefix := ""
+	if isPrefix {
+		start := parser.pos
+		for !parser.eof() && parser.peek() != ':' && isNameChar(parser.peek()) {
+			parser.pos++
+		}
+		prefix = string(parser.input[start:parser.pos])
+		if err := parser.expect(':'); err != nil {
+			return false, err
+		}
+		parser.skipSpace()
+	}
+	iri, err := parser.iriRef()
+	if err != nil {
+		return false, err
+	}
+	if isPrefix {
+		parser.prefixes[prefix] = string(iri)
+	} else {
+		base, err := url.Parse(string(iri))
+		if err != nil {
+			return false, parser.errorf("invalid base IRI: %s", iri)
+		}
+		parser.base = base
+	}
+	if !sparqlStyle {
+		if err := parser.expect('.'); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// triples parses a subject followed by its predicates and objects.
+func (parser *turtleParser) triples() error {
+	parser.skipSpace()
+	if !parser.ntriples && parser.peek() == '[' {
+		subject, err := parser.blankNodePropertyList()
+		if err != nil {
+			return err
+		}
+		parser.skipSpace()
+		if parser.peek() == '.' {
+			return nil
+		}
+		return parser.predicateObjectList(subject)
+	}
+	subject, err := parser.subject()
+	if err != nil {
+		return err
+	}
+	return parser.predicateObjectList(subject)
+}
+
+// subject parses the subject of a triple.
+func (parser *turtleParser) subject() (Term, error) {
+	parser.skipSpace()
+	switch parser.peek() {
+	case '_':
+		return parser.blankNode()
+	case '(':
+		if !parser.ntriples {
+			return parser.collection()
+		}
+	}
+	return parser.iri()
+}
+
+// predicateObjectList parses one or more predicates, separated by ';',
+// each followed by their objects.
+func (parser *turtleParser) predicateObjectList(subject Term) error {
+	for {
+		predicate, err := parser.verb()
+		if err != nil {
+			return err
+		}
+		if err := parser.objectList(subject, predicate); err != nil {
+			return err
+		}
+		if parser.ntriples {
+			return nil
+		}
+		parser.skipSpace()
+		if parser.peek() != ';' {
+			return nil
+		}
+		for parser.peek() == ';' {
+			parser.pos++
+			parser.skipSpace()
+		}
+		if char := parser.peek(); char == '.' || char == ']' || parser.eof() {
+			return nil
+		}
+	}
+}
+
+// objectList parses one or more objects, separated by ',', and records
+// a triple for each.
+func (parser *turtleParser) objectList(subject Term, predicate Term) error {
+	for {
+		object, err := parser.object()
+		if err != nil {
+			return err
+		}
+		parser.emit(subject, predicate, object)
+		if parser.ntriples {
+			return nil
+		}
+		parser.skipSpace()
+		if parser.peek() != ',' {
+			return nil
+		}
+		parser.pos++
+	}
+}
+
+// emit records a triple in the graph.
+func (parser *turtleParser) emit(subject Term, predicate Term, object Term) {
+	parser.graph = append(parser.graph, Triple{Subject: subject, Predicate: predicate, Object: object})
+}
+
+// verb parses a predicate, which may be given as 'a' for rdf:type.
+func (parser *turtleParser) verb() (Term, error) {
+	parser.skipSpace()
+	if !parser.ntriples && parser.peek() == 'a' && !isNameChar(parser.peekAt(1)) && parser.peekAt(1) != ':' {
+		parser.pos++
+		return IRI(rdfType), nil
+	}
+	return parser.iri()
+}
+
+// object parses the object of a triple.
+func (parser *turtleParser) object() (Term, error) {
+	parser.skipSpace()
+	char := parser.peek()
+	switch {
+	case char == '_':
+		return parser.blankNode()
+	case char == '"' || (char == '\'' && !parser.ntriples):
+		return parser.literal()
+	case parser.ntriples:
+		return parser.iri()
+	case char == '[':
+		return parser.blankNodePropertyList()
+	case char == '(':
+		return parser.collection()
+	case char == '+' || char == '-' || char == '.' || (char >= '0' && char <= '9'):
+		return parser.numeric()
+	case parser.keyword("true"):
+		return Literal{Value: "true", DataType: XSDBoolean}, nil
+	case parser.keyword("false"):
+		return Literal{Value: "false", DataType: XSDBoolean}, nil
+	}
+	return parser.iri()
+}
+
+// keyword consumes word if it appears next as a whole word.
+func (parser *turtleParser) keyword(word string) bool {
+	next := parser.peekAt(len(word))
+	if !parser.hasPrefix(word, false) || isNameChar(next) || next == ':' {
+		return false
+	}
+	parser.pos += len(word)
+	return true
+}
+
+// iri parses an IRI given either in full or as a prefixed name.
+func (parser *turtleParser) iri() (Term, error) {
+	parser.skipSpace()
+	if parser.peek() == '<' {
+		return parser.iriRef()
+	}
+	if parser.ntriples {
+		return nil, parser.errorf("expected IRI, found %s", parser.describeNext())
+	}
+	return parser.prefixedName()
+}
+
+// iriRef parses an IRI enclosed in angle brackets and resolves it
+// against the base IRI.
+func (parser *turtleParser) iriRef() (IRI, error) {
+	if err := parser.expect('<'); err != nil {
+		return "", err
+	}
+	var builder strings.Builder
+	for {
+		if parser.eof() {
+			return "", parser.errorf("unterminated IRI")
+		}
+		char := parser.peek()
+		parser.pos++
+		if char == '>' {
+			break
+		}
+		if char == '\\' {
+			decoded, err := parser.unicodeEscape()
+			if err != nil {
+				return "", err
+			}
+			char = decoded
+		} else if illegalIRIChar(char) {
+			return "", parser.errorf("invalid character in IRI: %q", char)
+		}
+		builder.WriteRune(char)
+	}
+	return parser.resolve(builder.String()), nil
+}
+
+// resolve resolves an IRI against the document's base, if it has one.
+func (parser *turtleParser) resolve(iri string) IRI {
+	if parser.base == nil {
+		return IRI(iri)
+	}
+	ref, err := url.Parse(iri)
+	if err != nil {
+		return IRI(iri)
+	}
+	return IRI(parser.base.ResolveReference(ref).String())
+}
+
+// unicodeEscape decodes a \u or \U escape, the backslash having already
+// been consumed.
+func (parser *turtleParser) unicodeEscape() (rune, error) {
+	size := 0
+	switch parser.peek() {
+	case 'u':
+		size = 4
+	case 'U':
+		size = 8
+	default:
+		return 0, parser.errorf("invalid escape sequence: \\%c", parser.peek())
+	}
+	parser.pos++
+	if parser.pos+size > len(parser.input) {
+		return 0, parser.errorf("truncated unicode escape")
+	}
+	hex := string(parser.input[parser.pos : parser.pos+size])
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return 0, parser.errorf("invalid unicode escape: %s", hex)
+	}
+	parser.pos += size
+	return rune(value), nil
+}
+
+// prefixedName parses a name such as wdt:P31 and expands it using the
+// prefixes declared so far.
+func (parser *turtleParser) prefixedName() (Term, error) {
+	start := parser.pos
+	for !parser.eof() && parser.peek() != ':' && isNameChar(parser.peek()) {
+		parser.pos++
+	}
+	prefix := string(parser.input[start:parser.pos])
+	if parser.peek() != ':' {
+		parser.pos = start
+		return nil, parser.errorf("expected IRI, found %s", parser.describeNext())
+	}
+	parser.pos++
+	namespace, ok := parser.prefixes[prefix]
+	if !ok {
+		return nil, parser.errorf("undeclared prefix: '%s'", prefix)
+	}
+	local, err := parser.localName()
+	if err != nil {
+		return nil, err
+	}
+	return IRI(namespace + local), nil
+}
+
+// localName parses the local part of a prefixed name, decoding any
+// escaped characters. A name cannot end with '.', which will be the end
+// of the statement instead.
+func (parser *turtleParser) localName() (string, error) {
+	var builder strings.Builder
+	for !parser.eof() {
+		char := parser.peek()
+		switch {
+		case char == '\\':
+			escaped := parser.peekAt(1)
+			if escaped == 0 || !strings.ContainsRune(`_~.-!$&'()*+,;=/?#@%`, escaped) {
+				return "", parser.errorf("invalid escape in local name: \\%c", escaped)
+			}
+			builder.WriteRune(escaped)
+			parser.pos += 2
+		case char == '%':
+			builder.WriteRune(char)
+			parser.pos++
+		case char == '.':
+			if next := parser.peekAt(1); !isNameChar(next) && next != ':' && next != '\\' && next != '%' && next != '.' {
+				return builder.String(), nil
+			}
+			builder.WriteRune(char)
+			parser.pos++
+		case char == ':' || isNameChar(char):
+			builder.WriteRune(char)
+			parser.pos++
+		default:
+			return builder.String(), nil
+		}
+	}
+	return builder.String(), nil
+}
+
+// isNameChar reports whether a character can appear in a prefix or
+// blank node label.
+func isNameChar(char rune) bool {
+	return char == '_' || char == '-' || char == 0xB7 || unicode.IsLetter(char) || unicode.IsDigit(char) || unicode.Is(unicode.Mn, char)
+}
+
+// blankNode parses a labelled blank node such as _:b0.
+func (parser *turtleParser) blankNode() (Term, error) {
+	if !parser.hasPrefix(blankNodeLabel, false) {
+		return nil, parser.errorf("expected blank node, found %s", parser.describeNext())
+	}
+	parser.pos += len(blankNodeLabel)
+	start := parser.pos
+	for !parser.eof() {
+		char := parser.peek()
+		if char == '.' && isNameChar(parser.peekAt(1)) {
+			parser.pos++
+			continue
+		}
+		if !isNameChar(char) {
+			break
+		}
+		parser.pos++
+	}
+	if parser.pos == start {
+		return nil, parser.errorf("empty blank node label")
+	}
+	return BlankNode(string(parser.input[start:parser.pos])), nil
+}
+
+// generatedLabelPrefix starts the labels given to anonymous resources.
+const generatedLabelPrefix string = "genid"
+
+// documentLabels returns the blank node labels used in a document, so
+// that the labels made up for anonymous resources can avoid them.
+// Anything that looks like a label is included, even inside a string
+// or IRI, which at worst skips a label that was free.
+func documentLabels(input []rune) map[string]bool {
+	labels := map[string]bool{}
+	for pos := 0; pos+1 < len(input); pos++ {
+		if input[pos] != '_' || input[pos+1] != ':' {
+			continue
+		}
+		end := pos + 2
+		for end < len(input) && (isNameChar(input[end]) || input[end] == '.') {
+			end++
+		}
+		labels[strings.TrimRight(string(input[pos+2:end]), ".")] = true
+		pos = end - 1
+	}
+	return labels
+}
+
+// newBlankNode returns a blank node for an anonymous resource, with a
+// label that isn't used anywhere in the document.
+func (parser *turtleParser) newBlankNode() BlankNode {
+	for {
+		parser.blanks++
+		label := fmt.Sprintf("%s%d", generatedLabelPrefix, parser.blanks)
+		if !parser.labels[label] {
+			return BlankNode(label)
+		}
+	}
+}
+
+// blankNodePropertyList parses an anonymous blank node, [ ... ], and
+// the triples it is the subject of.
+func (parser *turtleParser) blankNodePropertyList() (Term, error) {
+	if err := parser.expect('['); err != nil {
+		return nil, err
+	}
+	node := parser.newBlankNode()
+	parser.skipSpace()
+	if parser.peek() != ']' {
+		if err := parser.predicateObjectList(node); err != nil {
+			return nil, err
+		}
+	}
+	if err := parser.expect(']'); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// collection parses an RDF list, ( ... ), into rdf:first and rdf:rest
+// triples, returning the head of the list.
+func (parser *turtleParser) collection() (Term, error) {
+	if err := parser.expect('('); err != nil {
+		return nil, err
+	}
+	var head, previous Term = IRI(rdfNil), nil
+	for {
+		parser.skipSpace()
+		if parser.eof() {
+			return nil, parser.errorf("unterminated collection")
+		}
+		if parser.peek() == ')' {
+			parser.pos++
+			break
+		}
+		node := parser.newBlankNode()
+		if previous == nil {
+			head = node
+		} else {
+			parser.emit(previous, IRI(rdfRest), node)
+		}
+		item, err := parser.object()
+		if err != nil {
+			return nil, err
+		}
+		parser.emit(node, IRI(rdfFirst), item)
+		previous = node
+	}
+	if previous != nil {
+		parser.emit(previous, IRI(rdfRest), IRI(rdfNil))
+	}
+	return head, nil
+}
+
+// literal parses a quoted literal with an optional language tag or
+// datatype.
+func (parser *turtleParser) literal() (Term, error) {
+	value, err := parser.quotedString()
+	if err != nil {
+		return nil, err
+	}
+	literal := Literal{Value: value}
+	switch {
+	case parser.peek() == '@':
+		parser.pos++
+		start := parser.pos
+		for !parser.eof() && (parser.peek() == '-' || unicode.IsLetter(parser.peek()) || unicode.IsDigit(parser.peek())) {
+			parser.pos++
+		}
+		if parser.pos == start {
+			return nil, parser.errorf("empty language tag")
+		}
+		literal.Lang = string(parser.input[start:parser.pos])
+	case parser.hasPrefix("^^", false):
+		parser.pos += 2
+		datatype, err := parser.iri()
+		if err != nil {
+			return nil, err
+		}
+		// RDF 1.1 treats simple literals and xsd:string literals as
+		// the same, so we store them the same way too.
+		if datatype != IRI(XSDString) {
+			literal.DataType = string(datatype.(IRI))
+		}
+	}
+	return literal, nil
+}
+
+// quotedString parses a string in single, double or triple quotes,
+// decoding any escape sequences.
+func (parser *turtleParser) quotedString() (string, error) {
+	quote := parser.peek()
+	long := !parser.ntriples && parser.peekAt(1) == quote && parser.peekAt(2) == quote
+	if long {
+		parser.pos += 3
+	} else {
+		parser.pos++
+	}
+	var builder strings.Builder
+	for {
+		if parser.eof() {
+			return "", parser.errorf("unterminated string")
+		}
+		char := parser.peek()
+		if char == quote {
+			if !long {
+				parser.pos++
+				return builder.String(), nil
+			}
+			if parser.peekAt(1) == quote && parser.peekAt(2) == quote {
+				// Quotes immediately before the closing quotes belong
+				// to the string.
+				for parser.peekAt(3) == quote {
+					builder.WriteRune(quote)
+					parser.pos++
+				}
+				parser.pos += 3
+				return builder.String(), nil
+			}
+		}
+		if !long && (char == '\n' || char == '\r') {
+			return "", parser.errorf("unterminated string")
+		}
+		parser.pos++
+		if char != '\\' {
+			builder.WriteRune(char)
+			continue
+		}
+		escaped, err := parser.stringEscape()
+		if err != nil {
+			return "", err
+		}
+		builder.WriteRune(escaped)
+	}
+}
+
+// stringEscape decodes an escape sequence within a string, the
+// backslash having already been consumed.
+func (parser *turtleParser) stringEscape() (rune, error) {
+	char := parser.peek()
+	simple := map[rune]rune{
+		't': '\t', 'b': '\b', 'n': '\n', 'r': '\r', 'f': '\f',
+		'"': '"', '\'': '\'', '\\': '\\',
+	}
+	if decoded, ok := simple[char]; ok {
+		parser.pos++
+		return decoded, nil
+	}
+	return parser.unicodeEscape()
+}
+
+// numeric parses an integer, decimal or double literal.
+func (parser *turtleParser) numeric() (Term, error) {
+	start := parser.pos
+	if char := parser.peek(); char == '+' || char == '-' {
+		parser.pos++
+	}
+	digits := func() int {
+		count := 0
+		for parser.peek() >= '0' && parser.peek() <= '9' {
+			parser.pos++
+			count++
+		}
+		return count
+	}
+	datatype := XSDInteger
+	count := digits()
+	if parser.peek() == '.' && parser.peekAt(1) >= '0' && parser.peekAt(1) <= '9' {
+		parser.pos++
+		count += digits()
+		datatype = XSDDecimal
+	}
+	if char := parser.peek(); count > 0 && (char == 'e' || char == 'E') {
+		parser.pos++
+		if char := parser.peek(); char == '+' || char == '-' {
+			parser.pos++
+		}
+		if digits() == 0 {
+			return nil, parser.errorf("invalid exponent in number")
+		}
+		datatype = XSDDouble
+	}
+	if count == 0 {
+		parser.pos = start
+		return nil, parser.errorf("expected number, found %s", parser.describeNext())
+	}
+	return Literal{Value: string(parser.input[start:parser.pos]), DataType: datatype}, nil
+}
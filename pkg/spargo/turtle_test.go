@@ -0,0 +1,151 @@
+package spargo
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var testTurtle = `@prefix wd: <http://www.wikidata.org/entity/> .
+@prefix rdfs: <http://www.w3.org/2000/01/rdf-schema#> .
+PREFIX wdt: <http://www.wikidata.org/prop/direct/>
+@base <http://example.com/base/> .
+
+# JPEG 2000 in Wikidata.
+wd:Q931783 a <FileFormat> ;
+	rdfs:label "JPEG 2000"@en, 'JPEG 2000'@de ;
+	wdt:P2748 "x-fmt/392" ;
+	wdt:P1195 """jp2""" ;
+	wdt:P4152 [ wdt:P4153 0 ; wdt:P3294 -1.5 ] ;
+	wdt:P1163 ( "image/jp2" ) ;
+	<size> 1.0e3, true .
+_:a.b wdt:P31 _:c .
+`
+
+var expectedTurtle = Graph{
+	{IRI("http://www.wikidata.org/entity/Q931783"), IRI(rdfType), IRI("http://example.com/base/FileFormat")},
+	{IRI("http://www.wikidata.org/entity/Q931783"), IRI("http://www.w3.org/2000/01/rdf-schema#label"), Literal{Value: "JPEG 2000", Lang: "en"}},
+	{IRI("http://www.wikidata.org/entity/Q931783"), IRI("http://www.w3.org/2000/01/rdf-schema#label"), Literal{Value: "JPEG 2000", Lang: "de"}},
+	{IRI("http://www.wikidata.org/entity/Q931783"), IRI("http://www.wikidata.org/prop/direct/P2748"), Literal{Value: "x-fmt/392"}},
+	{IRI("http://www.wikidata.org/entity/Q931783"), IRI("http://www.wikidata.org/prop/direct/P1195"), Literal{Value: "jp2"}},
+	{BlankNode("genid1"), IRI("http://www.wikidata.org/prop/direct/P4153"), Literal{Value: "0", DataType: XSDInteger}},
+	{BlankNode("genid1"), IRI("http://www.wikidata.org/prop/direct/P3294"), Literal{Value: "-1.5", DataType: XSDDecimal}},
+	{IRI("http://www.wikidata.org/entity/Q931783"), IRI("http://www.wikidata.org/prop/direct/P4152"), BlankNode("genid1")},
+	{BlankNode("genid2"), IRI(rdfFirst), Literal{Value: "image/jp2"}},
+	{BlankNode("genid2"), IRI(rdfRest), IRI(rdfNil)},
+	{IRI("http://www.wikidata.org/entity/Q931783"), IRI("http://www.wikidata.org/prop/direct/P1163"), BlankNode("genid2")},
+	{IRI("http://www.wikidata.org/entity/Q931783"), IRI("http://example.com/base/size"), Literal{Value: "1.0e3", DataType: XSDDouble}},
+	{IRI("http://www.wikidata.org/entity/Q931783"), IRI("http://example.com/base/size"), Literal{Value: "true", DataType: XSDBoolean}},
+	{BlankNode("a.b"), IRI("http://www.wikidata.org/prop/direct/P31"), BlankNode("c")},
+}
+
+// TestParseTurtle makes sure the Turtle syntax we are likely to see in
+// response to CONSTRUCT and DESCRIBE queries is parsed correctly.
+func TestParseTurtle(t *testing.T) {
+	graph, err := ParseTurtle(strings.NewReader(testTurtle))
+	if err != nil {
+		t.Fatalf("Unexpected error parsing Turtle: %s", err)
+	}
+	if len(graph) != len(expectedTurtle) {
+		t.Fatalf("Expected %d triples, received %d:\n%s", len(expectedTurtle), len(graph), graph)
+	}
+	for i, triple := range graph {
+		if !reflect.DeepEqual(triple, expectedTurtle[i]) {
+			t.Errorf("Triple %d: expected %s, received %s", i, expectedTurtle[i], triple)
+		}
+	}
+}
+
+var testNTriples = `<http://www.wikidata.org/entity/Q931783> <http://www.w3.org/2000/01/rdf-schema#label> "JPEG \"2000\"\n\u00E9"@en .
+# A comment.
+<http://www.wikidata.org/entity/Q931783> <http://www.wikidata.org/prop/direct/P2748> "x-fmt/392"^^<http://www.w3.org/2001/XMLSchema#string> .
+_:b0 <http://www.wikidata.org/prop/direct/P31> <http://www.wikidata.org/entity/Q235557> .
+`
+
+// TestParseNTriples makes sure N-Triples is parsed and that the graph
+// can be written back out again.
+func TestParseNTriples(t *testing.T) {
+	graph, err := ParseNTriples(strings.NewReader(testNTriples))
+	if err != nil {
+		t.Fatalf("Unexpected error parsing N-Triples: %s", err)
+	}
+	if len(graph) != 3 {
+		t.Fatalf("Expected 3 triples, received %d", len(graph))
+	}
+	if graph[0].Object != (Literal{Value: "JPEG \"2000\"\né", Lang: "en"}) {
+		t.Errorf("Unexpected literal: %#v", graph[0].Object)
+	}
+	if graph[2].Subject != BlankNode("b0") {
+		t.Errorf("Unexpected blank node: %#v", graph[2].Subject)
+	}
+	roundTrip, err := ParseNTriples(strings.NewReader(graph.String()))
+	if err != nil {
+		t.Fatalf("Unexpected error parsing serialized graph: %s", err)
+	}
+	if !reflect.DeepEqual(graph[:2], roundTrip[:2]) {
+		t.Errorf("Graph did not survive a round trip:\n%s\n%s", graph, roundTrip)
+	}
+}
+
+// TestBlankNodeLabels makes sure labels made up for anonymous
+// resources avoid those used in a document, which are kept as they are.
+func TestBlankNodeLabels(t *testing.T) {
+	document := `_:genid1 <http://example.com/p> "a" .
+<http://example.com/s> <http://example.com/q> [ <http://example.com/p> "b" ] .
+_:b0 <http://example.com/p> _:genid2 .`
+	graph, err := ParseTurtle(strings.NewReader(document))
+	if err != nil {
+		t.Fatalf("Unexpected error parsing Turtle: %s", err)
+	}
+	if len(graph) != 4 {
+		t.Fatalf("Expected 4 triples, received %d", len(graph))
+	}
+	if graph[0].Subject != BlankNode("genid1") || graph[3].Object != BlankNode("genid2") {
+		t.Errorf("Expected document labels to be kept:\n%s", graph)
+	}
+	if graph[1].Subject != BlankNode("genid3") || graph[2].Object != graph[1].Subject {
+		t.Errorf("Expected an unused label for the anonymous resource:\n%s", graph)
+	}
+	roundTrip, err := ParseTurtle(strings.NewReader(graph.String()))
+	if err != nil {
+		t.Fatalf("Unexpected error parsing serialized graph: %s", err)
+	}
+	if !reflect.DeepEqual(graph, roundTrip) {
+		t.Errorf("Graph did not survive a round trip:\n%s\n%s", graph, roundTrip)
+	}
+
+	// Labels are the same whichever format they are read from.
+	triples, err := ParseNTriples(strings.NewReader(graph.String()))
+	if err != nil || !reflect.DeepEqual(graph, triples) {
+		t.Errorf("Unexpected N-Triples labels: %v\n%s", err, triples)
+	}
+	res, err := DecodeTSVResults(strings.NewReader("?x\n_:genid1\n"))
+	if err != nil || res.Results.Bindings[0]["x"].Value != "genid1" {
+		t.Errorf("Unexpected TSV label: %v %v", err, res.Results.Bindings)
+	}
+}
+
+// TestParseRDFErrors makes sure invalid documents are reported, and that
+// Turtle-only syntax is rejected in N-Triples.
+func TestParseRDFErrors(t *testing.T) {
+	invalid := []struct {
+		ntriples bool
+		document string
+	}{
+		{false, `wd:Q1 <p> <o> .`},
+		{false, `<s> <p> "unterminated .`},
+		{false, `<s> <p> <o>`},
+		{false, `<s> <p> <o o> .`},
+		{true, `<s> a <o> .`},
+		{true, `<s> <p> <o> ; <p> <o> .`},
+		{true, `@prefix ex: <http://example.com/> .`},
+	}
+	for _, val := range invalid {
+		_, err := parseRDF(strings.NewReader(val.document), val.ntriples)
+		syntaxErr := RDFSyntaxError{}
+		if !errors.As(err, &syntaxErr) {
+			t.Errorf("Expected a syntax error for '%s', received: %v", val.document, err)
+		}
+	}
+}
@@ -0,0 +1,305 @@
+package spargo
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// UnmarshalError describes a problem mapping a SPARQL result to a Go
+// struct.
+type UnmarshalError struct {
+	Row      int
+	Variable string
+	Field    string
+	Err      error
+}
+
+// Error enables UnmarshalError to implement the Errors interface.
+func (err UnmarshalError) Error() string {
+	if err.Row < 0 {
+		return fmt.Sprintf("spargo: cannot unmarshal ?%s into field %s: %s", err.Variable, err.Field, err.Err)
+	}
+	return fmt.Sprintf("spargo: cannot unmarshal ?%s into field %s in row %d: %s", err.Variable, err.Field, err.Row, err.Err)
+}
+
+// Unwrap returns the underlying error.
+func (err UnmarshalError) Unwrap() error {
+	return err.Err
+}
+
+// Types given special treatment by Unmarshal.
+var (
+	itemType = reflect.TypeOf(Item{})
+	timeType = reflect.TypeOf(time.Time{})
+	termType = reflect.TypeOf((*Term)(nil)).Elem()
+)
+
+// boundField describes a struct field mapped to a SPARQL variable.
+type boundField struct {
+	index    int
+	name     string
+	variable string
+	optional bool
+	multi    bool
+}
+
+// Unmarshal maps the bindings of a SELECT result onto a slice of structs.
+// dst must be a pointer to a slice of structs, or of pointers to structs.
+// Struct fields are mapped to variables using a sparql tag, e.g.
+//
+//	type Format struct {
+//		URI        string    `sparql:"uri"`
+//		PUID       *string   `sparql:"puid"`
+//		Extensions []string  `sparql:"extension"`
+//		Date       time.Time `sparql:"date"`
+//	}
+//
+// Fields without a tag, or tagged "-", are ignored. The value of each
+// variable is converted to the type of its field using the typed
+// accessors on Item, so e.g. an int field requires an XSD integer.
+// Fields may also be a string, which receives the value as it is, an
+// Item or a Term.
+//
+// Pointer fields are optional, e.g. for variables in an OPTIONAL block,
+// and are left nil if the variable is unbound. Other fields are required
+// and an error wrapping ErrUnboundVariable is returned if their variable
+// is unbound.
+//
+// Slice fields are multi-valued. Rows that agree on every other field
+// are merged into a single struct, and the distinct values of slice
+// fields across those rows are collected, in the order they are seen.
+func Unmarshal(result SPARQLResult, dst interface{}) error {
+	ptr := reflect.ValueOf(dst)
+	if ptr.Kind() != reflect.Ptr || ptr.IsNil() || ptr.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("spargo: unmarshal needs a pointer to a slice, not %T", dst)
+	}
+	slice := ptr.Elem()
+	elemType := slice.Type().Elem()
+	structType := elemType
+	if elemType.Kind() == reflect.Ptr {
+		structType = elemType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return fmt.Errorf("spargo: unmarshal needs a slice of structs, not %s", slice.Type())
+	}
+
+	fields, err := sparqlFields(structType)
+	if err != nil {
+		return err
+	}
+	if err := checkVars(result, fields); err != nil {
+		return err
+	}
+
+	multi := false
+	for _, field := range fields {
+		multi = multi || field.multi
+	}
+
+	out := reflect.MakeSlice(slice.Type(), 0, len(result.Results.Bindings))
+	groups := make(map[string]int)
+
+	for row, binding := range result.Results.Bindings {
+		var target reflect.Value
+		key := groupKey(fields, binding)
+		if index, ok := groups[key]; multi && ok {
+			target = out.Index(index)
+		} else {
+			value := reflect.New(structType)
+			if err := setSingleFields(value.Elem(), fields, binding, row); err != nil {
+				return err
+			}
+			if elemType.Kind() == reflect.Ptr {
+				out = reflect.Append(out, value)
+			} else {
+				out = reflect.Append(out, value.Elem())
+			}
+			groups[key] = out.Len() - 1
+			target = out.Index(out.Len() - 1)
+		}
+		if target.Kind() == reflect.Ptr {
+			target = target.Elem()
+		}
+		if err := appendMultiFields(target, fields, binding, row); err != nil {
+			return err
+		}
+	}
+
+	slice.Set(out)
+	return nil
+}
+
+// sparqlFields returns the fields of a struct that have a sparql tag.
+func sparqlFields(structType reflect.Type) ([]boundField, error) {
+	var fields []boundField
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		tag := strings.TrimSpace(field.Tag.Get("sparql"))
+		if tag == "" || tag == "-" {
+			continue
+		}
+		if field.PkgPath != "" {
+			return nil, fmt.Errorf("spargo: cannot unmarshal into unexported field %s", field.Name)
+		}
+		bound := boundField{
+			index:    i,
+			name:     field.Name,
+			variable: strings.TrimLeft(tag, "?$"),
+			optional: field.Type.Kind() == reflect.Ptr,
+			multi:    field.Type.Kind() == reflect.Slice,
+		}
+		fields = append(fields, bound)
+	}
+	return fields, nil
+}
+
+// checkVars makes sure that the variables needed by required fields
+// are listed in the head of the result, so that a mistyped tag is
+// reported even when there are no rows.
+func checkVars(result SPARQLResult, fields []boundField) error {
+	vars := headVars(result.Head)
+	if len(vars) == 0 {
+		return nil
+	}
+	listed := make(map[string]bool)
+	for _, variable := range vars {
+		listed[variable] = true
+	}
+	for _, field := range fields {
+		if field.optional || field.multi || listed[field.variable] {
+			continue
+		}
+		return UnmarshalError{Row: -1, Variable: field.variable, Field: field.name, Err: fmt.Errorf("variable is not in the result")}
+	}
+	return nil
+}
+
+// groupKey identifies the rows that will be merged into a struct: those
+// with the same values for all fields that are not multi-valued.
+func groupKey(fields []boundField, binding map[string]Item) string {
+	var key strings.Builder
+	for _, field := range fields {
+		if field.multi {
+			continue
+		}
+		item, ok := binding[field.variable]
+		fmt.Fprintf(&key, "%t\x00%s\x00%s\x00%s\x00%s\x00", ok, item.Type, item.Value, item.Lang, item.DataType)
+	}
+	return key.String()
+}
+
+// setSingleFields sets the fields of a new struct that are not
+// multi-valued.
+func setSingleFields(target reflect.Value, fields []boundField, binding map[string]Item, row int) error {
+	for _, field := range fields {
+		if field.multi {
+			continue
+		}
+		item, ok := binding[field.variable]
+		if !ok {
+			if field.optional {
+				continue
+			}
+			return UnmarshalError{Row: row, Variable: field.variable, Field: field.name, Err: ErrUnboundVariable}
+		}
+		value := target.Field(field.index)
+		if field.optional {
+			value.Set(reflect.New(value.Type().Elem()))
+			value = value.Elem()
+		}
+		if err := setValue(value, item); err != nil {
+			return UnmarshalError{Row: row, Variable: field.variable, Field: field.name, Err: err}
+		}
+	}
+	return nil
+}
+
+// appendMultiFields adds the values in a row to the multi-valued fields
+// of a struct, if they are not already there.
+func appendMultiFields(target reflect.Value, fields []boundField, binding map[string]Item, row int) error {
+	for _, field := range fields {
+		if !field.multi {
+			continue
+		}
+		item, ok := binding[field.variable]
+		if !ok {
+			continue
+		}
+		values := target.Field(field.index)
+		value := reflect.New(values.Type().Elem()).Elem()
+		if err := setValue(value, item); err != nil {
+			return UnmarshalError{Row: row, Variable: field.variable, Field: field.name, Err: err}
+		}
+		duplicate := false
+		for i := 0; i < values.Len() && !duplicate; i++ {
+			duplicate = reflect.DeepEqual(values.Index(i).Interface(), value.Interface())
+		}
+		if !duplicate {
+			values.Set(reflect.Append(values, value))
+		}
+	}
+	return nil
+}
+
+// setValue converts an item to the type of value and sets it.
+func setValue(value reflect.Value, item Item) error {
+	switch value.Type() {
+	case itemType:
+		value.Set(reflect.ValueOf(item))
+		return nil
+	case timeType:
+		converted, err := item.AsTime()
+		if err != nil {
+			return err
+		}
+		value.Set(reflect.ValueOf(converted))
+		return nil
+	case termType:
+		term, err := TermFromItem(item)
+		if err != nil {
+			return err
+		}
+		value.Set(reflect.ValueOf(&term).Elem())
+		return nil
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(item.Value)
+	case reflect.Bool:
+		converted, err := item.AsBool()
+		if err != nil {
+			return err
+		}
+		value.SetBool(converted)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		converted, err := item.AsInt()
+		if err != nil {
+			return err
+		}
+		if value.OverflowInt(converted) {
+			return fmt.Errorf("%d overflows %s", converted, value.Type())
+		}
+		value.SetInt(converted)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		converted, err := item.AsBigInt()
+		if err != nil {
+			return err
+		}
+		if converted.Sign() < 0 || !converted.IsUint64() || value.OverflowUint(converted.Uint64()) {
+			return fmt.Errorf("%s overflows %s", converted, value.Type())
+		}
+		value.SetUint(converted.Uint64())
+	case reflect.Float32, reflect.Float64:
+		converted, err := item.AsFloat()
+		if err != nil {
+			return err
+		}
+		value.SetFloat(converted)
+	default:
+		return fmt.Errorf("unsupported field type %s", value.Type())
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package spargo
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testFormats = `{
+  "head": {"vars": ["uri", "label", "puid", "extension", "date", "offset"]},
+  "results": {
+    "bindings": [
+      {
+        "uri": {"type": "uri", "value": "http://www.wikidata.org/entity/Q931783"},
+        "label": {"type": "literal", "value": "JPEG 2000", "xml:lang": "en"},
+        "puid": {"type": "literal", "value": "x-fmt/392"},
+        "extension": {"type": "literal", "value": "jp2"},
+        "date": {"type": "literal", "value": "2019-06-01T00:00:00Z", "datatype": "http://www.w3.org/2001/XMLSchema#dateTime"},
+        "offset": {"type": "literal", "value": "4", "datatype": "http://www.w3.org/2001/XMLSchema#integer"}
+      },
+      {
+        "uri": {"type": "uri", "value": "http://www.wikidata.org/entity/Q931783"},
+        "label": {"type": "literal", "value": "JPEG 2000", "xml:lang": "en"},
+        "puid": {"type": "literal", "value": "x-fmt/392"},
+        "extension": {"type": "literal", "value": "j2k"},
+        "date": {"type": "literal", "value": "2019-06-01T00:00:00Z", "datatype": "http://www.w3.org/2001/XMLSchema#dateTime"},
+        "offset": {"type": "literal", "value": "4", "datatype": "http://www.w3.org/2001/XMLSchema#integer"}
+      },
+      {
+        "uri": {"type": "uri", "value": "http://www.wikidata.org/entity/Q2195"},
+        "label": {"type": "literal", "value": "JPEG", "xml:lang": "en"},
+        "date": {"type": "literal", "value": "1992-09-18", "datatype": "http://www.w3.org/2001/XMLSchema#date"},
+        "offset": {"type": "literal", "value": "0", "datatype": "http://www.w3.org/2001/XMLSchema#integer"}
+      }
+    ]
+  }
+}`
+
+// testFormat maps the results in testFormats.
+type testFormat struct {
+	URI        Term      `sparql:"uri"`
+	Label      Item      `sparql:"label"`
+	PUID       *string   `sparql:"puid"`
+	Extensions []string  `sparql:"extension"`
+	Date       time.Time `sparql:"date"`
+	Offset     int       `sparql:"offset"`
+	Ignored    string
+	Skipped    string `sparql:"-"`
+}
+
+// TestUnmarshal makes sure rows are mapped onto structs, with optional
+// fields left nil and multi-valued fields merged.
+func TestUnmarshal(t *testing.T) {
+	res, err := DecodeJSONResults(strings.NewReader(testFormats))
+	if err != nil {
+		t.Fatalf("Unexpected error decoding results: %s", err)
+	}
+
+	var formats []testFormat
+	if err := Unmarshal(res, &formats); err != nil {
+		t.Fatalf("Unexpected error from Unmarshal: %s", err)
+	}
+	if len(formats) != 2 {
+		t.Fatalf("Expected rows to be merged into 2 formats, received %d: %+v", len(formats), formats)
+	}
+
+	jp2 := formats[0]
+	if jp2.URI != IRI("http://www.wikidata.org/entity/Q931783") || jp2.Label.Lang != "en" {
+		t.Errorf("Unexpected URI or label: %+v", jp2)
+	}
+	if jp2.PUID == nil || *jp2.PUID != "x-fmt/392" {
+		t.Errorf("Expected PUID x-fmt/392, received: %v", jp2.PUID)
+	}
+	if !reflect.DeepEqual(jp2.Extensions, []string{"jp2", "j2k"}) {
+		t.Errorf("Expected merged extensions, received: %v", jp2.Extensions)
+	}
+	if !jp2.Date.Equal(time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC)) || jp2.Offset != 4 {
+		t.Errorf("Unexpected typed values: %+v", jp2)
+	}
+
+	jpeg := formats[1]
+	if jpeg.PUID != nil || jpeg.Extensions != nil {
+		t.Errorf("Expected unbound optional values to be empty: %+v", jpeg)
+	}
+
+	var pointers []*testFormat
+	if err := Unmarshal(res, &pointers); err != nil || len(pointers) != 2 {
+		t.Errorf("Expected to unmarshal into a slice of pointers: %v", err)
+	}
+}
+
+// TestUnmarshalErrors makes sure problems mapping results are clearly
+// reported.
+func TestUnmarshalErrors(t *testing.T) {
+	res, _ := DecodeJSONResults(strings.NewReader(testFormats))
+
+	var required []struct {
+		PUID string `sparql:"puid"`
+	}
+	err := Unmarshal(res, &required)
+	unmarshalErr := UnmarshalError{}
+	if !errors.Is(err, ErrUnboundVariable) || !errors.As(err, &unmarshalErr) || unmarshalErr.Row != 2 {
+		t.Errorf("Expected an unbound variable error for row 2, received: %v", err)
+	}
+
+	var missing []struct {
+		Sig string `sparql:"sig"`
+	}
+	if err := Unmarshal(res, &missing); !errors.As(err, &unmarshalErr) || unmarshalErr.Variable != "sig" {
+		t.Errorf("Expected an error for a variable not in the result, received: %v", err)
+	}
+
+	var mismatch []struct {
+		Label int `sparql:"label"`
+	}
+	if err := Unmarshal(res, &mismatch); !errors.Is(err, ErrDatatypeMismatch) {
+		t.Errorf("Expected a datatype mismatch, received: %v", err)
+	}
+
+	var small []struct {
+		Offset int8 `sparql:"offset"`
+	}
+	if err := Unmarshal(res, &small); err != nil {
+		t.Errorf("Unexpected error for small integers: %v", err)
+	}
+
+	var notSlice testFormat
+	if err := Unmarshal(res, &notSlice); err == nil {
+		t.Errorf("Expected an error unmarshalling into a struct")
+	}
+}
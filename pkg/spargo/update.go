@@ -0,0 +1,114 @@
+package spargo
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Content type used when sending an update directly in the body of a
+// POST.
+const updateContentType string = "application/sparql-update"
+
+// UpdateOptions describes the optional parts of a SPARQL 1.1 Update
+// request.
+type UpdateOptions struct {
+	// UsingGraphURIs are sent as using-graph-uri parameters, which
+	// describe the default graph the update's WHERE clause is
+	// evaluated against.
+	UsingGraphURIs []string
+	// UsingNamedGraphURIs are sent as using-named-graph-uri
+	// parameters, which describe the named graphs the update's WHERE
+	// clause is evaluated against.
+	UsingNamedGraphURIs []string
+	// Direct sends the update unencoded as an application/sparql-update
+	// POST body. Otherwise it is sent URL-encoded as a form.
+	Direct bool
+	// Retry applies the client's RetryPolicy to the update. Updates
+	// are not idempotent, an update whose response is lost after it
+	// was applied would be applied again, so by default they are sent
+	// only once.
+	Retry bool
+}
+
+// SPARQLUpdate sends a SPARQL 1.1 Update operation, e.g. INSERT DATA,
+// DELETE/INSERT, LOAD or CLEAR, to the client's update endpoint. A
+// failed update is reported using ResponseError.
+func (endpoint *SPARQLClient) SPARQLUpdate(update string, options UpdateOptions) error {
+	return endpoint.SPARQLUpdateContext(context.Background(), update, options)
+}
+
+// SPARQLUpdateContext behaves like SPARQLUpdate but ties the request to
+// ctx so that it can be cancelled by the caller.
+func (endpoint *SPARQLClient) SPARQLUpdateContext(ctx context.Context, update string, options UpdateOptions) error {
+	update = endpoint.declarePrefixes(update)
+	build := func(ctx context.Context) (*http.Request, error) {
+		return endpoint.newUpdateRequest(ctx, update, options)
+	}
+	setupClient(endpoint)
+	client := *endpoint
+	if !options.Retry {
+		client.Retry = nil
+	}
+	_, _, err := client.fetch(ctx, build, status2xx)
+	return err
+}
+
+// status2xx reports whether a status code is a successful response to
+// an update. Endpoints variously respond 200 or 204 on success.
+func status2xx(code int) bool {
+	return code >= 200 && code < 300
+}
+
+// updateURL returns the URL updates are sent to. If no update endpoint
+// has been set the query endpoint is used, as some stores accept both
+// at the same address.
+func (endpoint *SPARQLClient) updateURL() string {
+	if endpoint.UpdateURL != "" {
+		return endpoint.UpdateURL
+	}
+	return endpoint.BaseURL
+}
+
+// newUpdateRequest packages an update as a http.Request. Updates are
+// always sent using POST.
+func (endpoint *SPARQLClient) newUpdateRequest(ctx context.Context, update string, options UpdateOptions) (*http.Request, error) {
+	params := url.Values{}
+	for _, uri := range options.UsingGraphURIs {
+		params.Add("using-graph-uri", uri)
+	}
+	for _, uri := range options.UsingNamedGraphURIs {
+		params.Add("using-named-graph-uri", uri)
+	}
+
+	var req *http.Request
+	var err error
+
+	if options.Direct {
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, endpoint.updateURL(), strings.NewReader(update))
+		if err != nil {
+			return nil, err
+		}
+		// With a direct POST the dataset parameters go in the URL.
+		existing := req.URL.Query()
+		for key, values := range params {
+			for _, value := range values {
+				existing.Add(key, value)
+			}
+		}
+		req.URL.RawQuery = existing.Encode()
+		req.Header.Set("Content-Type", updateContentType)
+	} else {
+		params.Add("update", update)
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, endpoint.updateURL(), strings.NewReader(params.Encode()))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", formContentType)
+	}
+
+	req.Header.Add("User-Agent", endpoint.Agent)
+
+	return req, nil
+}
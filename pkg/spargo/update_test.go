@@ -0,0 +1,120 @@
+package spargo
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+var testUpdate = `INSERT DATA {
+	<http://the-fr.org/id/file-format/25> <http://www.w3.org/2000/01/rdf-schema#label> "OS/2 Bitmap"@en .
+}`
+
+// TestUpdateForm makes sure an update is sent URL-encoded to the update
+// endpoint along with its dataset parameters.
+func TestUpdateForm(t *testing.T) {
+	captured := capturedRequest{}
+	sparql := SPARQLClient{}
+	sparql.Client = newCapturingClient(&captured, 204)
+	sparql.ClientInit("http://example.com/ds/query", "")
+	sparql.SetUpdateURL("http://example.com/ds/update")
+
+	options := UpdateOptions{
+		UsingGraphURIs:      []string{"http://example.com/g/pronom"},
+		UsingNamedGraphURIs: []string{"http://example.com/g/wikidata", "http://example.com/g/fdd"},
+	}
+	if err := sparql.SPARQLUpdate(testUpdate, options); err != nil {
+		t.Fatalf("Unexpected error from SPARQLUpdate: %s", err)
+	}
+
+	if captured.method != "POST" || captured.contentType != formContentType {
+		t.Errorf("Expected a form POST, received %s '%s'", captured.method, captured.contentType)
+	}
+	if captured.url.String() != "http://example.com/ds/update" {
+		t.Errorf("Expected the update to be sent to the update endpoint, not %s", captured.url)
+	}
+	form, err := url.ParseQuery(captured.body)
+	if err != nil {
+		t.Fatalf("Cannot parse form body: %s", err)
+	}
+	if form.Get("update") != testUpdate {
+		t.Errorf("Unexpected update in body: '%s'", form.Get("update"))
+	}
+	if !reflect.DeepEqual(form["using-graph-uri"], options.UsingGraphURIs) {
+		t.Errorf("Unexpected using-graph-uri: %s", form["using-graph-uri"])
+	}
+	if !reflect.DeepEqual(form["using-named-graph-uri"], options.UsingNamedGraphURIs) {
+		t.Errorf("Unexpected using-named-graph-uri: %s", form["using-named-graph-uri"])
+	}
+}
+
+// TestUpdateDirect makes sure an update can be sent directly in the
+// request body, with dataset parameters in the URL, and that the query
+// endpoint is used when no update endpoint is set.
+func TestUpdateDirect(t *testing.T) {
+	captured := capturedRequest{}
+	sparql := SPARQLClient{}
+	sparql.Client = newCapturingClient(&captured, 200)
+	sparql.ClientInit("http://example.com/sparql", "")
+
+	options := UpdateOptions{UsingGraphURIs: []string{"http://example.com/g/pronom"}, Direct: true}
+	if err := sparql.SPARQLUpdate(testUpdate, options); err != nil {
+		t.Fatalf("Unexpected error from SPARQLUpdate: %s", err)
+	}
+
+	if captured.contentType != updateContentType {
+		t.Errorf("Expected content type '%s', received '%s'", updateContentType, captured.contentType)
+	}
+	if captured.body != testUpdate {
+		t.Errorf("Unexpected update in body: '%s'", captured.body)
+	}
+	if captured.url.Path != "/sparql" || captured.url.Query().Get("using-graph-uri") != "http://example.com/g/pronom" {
+		t.Errorf("Unexpected update URL: %s", captured.url)
+	}
+}
+
+// TestUpdateFailure makes sure a failed update is reported using a
+// ResponseError.
+func TestUpdateFailure(t *testing.T) {
+	captured := capturedRequest{}
+	sparql := SPARQLClient{}
+	sparql.Client = newCapturingClient(&captured, 400)
+	sparql.ClientInit("http://example.com/sparql", "")
+
+	err := sparql.SPARQLUpdate("CLEAR GRAF <http://example.com/g/fdd>", UpdateOptions{})
+	responseTest := ResponseError{}
+	if !errors.As(err, &responseTest) {
+		t.Errorf("Expected a ResponseError from SPARQLUpdate, received: %v", err)
+	}
+}
+
+// TestUpdateRetry makes sure updates are only retried when asked to,
+// as they may already have been applied.
+func TestUpdateRetry(t *testing.T) {
+	_, restore := mockSleep()
+	defer restore()
+	calls := 0
+	sparql := SPARQLClient{}
+	sparql.Client = newSequenceClient([]int{503}, nil, &calls)
+	sparql.ClientInit("http://example.com/sparql", "")
+	sparql.SetRetryPolicy(&RetryPolicy{MaxAttempts: 3})
+
+	if err := sparql.SPARQLUpdate(testUpdate, UpdateOptions{}); err == nil {
+		t.Errorf("Expected the failed update to be reported")
+	}
+	if calls != 1 {
+		t.Errorf("Expected the update to be sent once, sent %d times", calls)
+	}
+
+	calls = 0
+	if err := sparql.SPARQLUpdate(testUpdate, UpdateOptions{Retry: true}); err != nil {
+		t.Errorf("Unexpected error from SPARQLUpdate: %s", err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected the update to be retried once, sent %d times", calls)
+	}
+	if sparql.Retry == nil {
+		t.Errorf("The client's retry policy should be left in place")
+	}
+}
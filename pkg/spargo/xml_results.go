@@ -0,0 +1,130 @@
+package spargo
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+/*
+SPARQL results in XML will be returned as follows:
+
+	<sparql xmlns="http://www.w3.org/2005/sparql-results#">
+	  <head>
+	    <variable name="item"/>
+	    <variable name="itemLabel"/>
+	  </head>
+	  <results>
+	    <result>
+	      <binding name="item">
+	        <uri>http://www.wikidata.org/entity/Q28114535</uri>
+	      </binding>
+	      <binding name="itemLabel">
+	        <literal xml:lang="en">Mr. White</literal>
+	      </binding>
+	    </result>
+	  </results>
+	</sparql>
+
+The response to an ASK query contains a <boolean> element in place of
+<results>.
+*/
+
+// xmlResults mirrors the structure of the SPARQL Query Results XML
+// Format.
+type xmlResults struct {
+	XMLName   xml.Name `xml:"http://www.w3.org/2005/sparql-results# sparql"`
+	Variables []struct {
+		Name string `xml:"name,attr"`
+	} `xml:"head>variable"`
+	Links []struct {
+		Href string `xml:"href,attr"`
+	} `xml:"head>link"`
+	Results []struct {
+		Bindings []xmlBinding `xml:"binding"`
+	} `xml:"results>result"`
+	Boolean *string `xml:"boolean"`
+}
+
+// xmlBinding is a single bound variable in an XML result.
+type xmlBinding struct {
+	Name    string  `xml:"name,attr"`
+	URI     *string `xml:"uri"`
+	BNode   *string `xml:"bnode"`
+	Literal *struct {
+		Lang     string `xml:"http://www.w3.org/XML/1998/namespace lang,attr"`
+		DataType string `xml:"datatype,attr"`
+		Value    string `xml:",chardata"`
+	} `xml:"literal"`
+}
+
+// item converts an XML binding to the Item used in SPARQLResult.
+func (binding xmlBinding) item() (Item, error) {
+	switch {
+	case binding.URI != nil:
+		return Item{Type: "uri", Value: *binding.URI}, nil
+	case binding.BNode != nil:
+		return Item{Type: "bnode", Value: *binding.BNode}, nil
+	case binding.Literal != nil:
+		return Item{
+			Type:     "literal",
+			Value:    binding.Literal.Value,
+			Lang:     binding.Literal.Lang,
+			DataType: binding.Literal.DataType,
+		}, nil
+	}
+	return Item{}, fmt.Errorf("spargo: binding for '%s' has no value", binding.Name)
+}
+
+// DecodeXMLResults reads SPARQL results in the SPARQL Query Results XML
+// Format into the same structures used for JSON results.
+func DecodeXMLResults(reader io.Reader) (SPARQLResult, error) {
+	var decoded xmlResults
+	if err := xml.NewDecoder(reader).Decode(&decoded); err != nil {
+		return SPARQLResult{}, err
+	}
+
+	var sparqlResponse SPARQLResult
+	head := make(map[string]interface{})
+	if len(decoded.Variables) > 0 {
+		vars := make([]interface{}, 0, len(decoded.Variables))
+		for _, variable := range decoded.Variables {
+			vars = append(vars, variable.Name)
+		}
+		head["vars"] = vars
+	}
+	if len(decoded.Links) > 0 {
+		links := make([]interface{}, 0, len(decoded.Links))
+		for _, link := range decoded.Links {
+			links = append(links, link.Href)
+		}
+		head["link"] = links
+	}
+	sparqlResponse.Head = head
+
+	if decoded.Boolean != nil {
+		switch value := strings.TrimSpace(*decoded.Boolean); value {
+		case "true", "false":
+			answer := value == "true"
+			sparqlResponse.Boolean = &answer
+		default:
+			return SPARQLResult{}, fmt.Errorf("spargo: invalid boolean result: '%s'", value)
+		}
+		return sparqlResponse, nil
+	}
+
+	sparqlResponse.Results.Bindings = make([]map[string]Item, 0, len(decoded.Results))
+	for _, result := range decoded.Results {
+		row := make(map[string]Item, len(result.Bindings))
+		for _, binding := range result.Bindings {
+			item, err := binding.item()
+			if err != nil {
+				return SPARQLResult{}, err
+			}
+			row[binding.Name] = item
+		}
+		sparqlResponse.Results.Bindings = append(sparqlResponse.Results.Bindings, row)
+	}
+	return sparqlResponse, nil
+}
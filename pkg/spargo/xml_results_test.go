@@ -0,0 +1,64 @@
+package spargo
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestDecodeXMLResults makes sure XML results decode to the same
+// structures as their JSON equivalent.
+func TestDecodeXMLResults(t *testing.T) {
+	fromXML, err := DecodeXMLResults(strings.NewReader(testXMLString))
+	if err != nil {
+		t.Fatalf("Unexpected error decoding XML results: %s", err)
+	}
+	fromJSON, err := DecodeJSONResults(strings.NewReader(testString))
+	if err != nil {
+		t.Fatalf("Unexpected error decoding JSON results: %s", err)
+	}
+	if !reflect.DeepEqual(fromXML, fromJSON) {
+		t.Errorf("XML and JSON results differ:\n%s\n%s", fromXML, fromJSON)
+	}
+
+	ask, err := DecodeXMLResults(strings.NewReader(testXMLAsk))
+	if err != nil {
+		t.Fatalf("Unexpected error decoding XML boolean result: %s", err)
+	}
+	if !ask.IsBoolean() || !*ask.Boolean {
+		t.Errorf("Expected a true boolean result, received: %s", ask)
+	}
+
+	if _, err := DecodeXMLResults(strings.NewReader(`<sparql/>`)); err == nil {
+		t.Errorf("Expected an error decoding XML outside of the SPARQL results namespace")
+	}
+}
+
+// TestSparqlHandlerXML makes sure SPARQLGo decodes XML results when the
+// server says that is what it has sent.
+func TestSparqlHandlerXML(t *testing.T) {
+	httpClient := NewTestClient(func(req *http.Request) *http.Response {
+		header := make(http.Header)
+		header.Set("Content-Type", "application/sparql-results+xml; charset=UTF-8")
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(bytes.NewBufferString(testXMLString)),
+			Header:     header,
+		}
+	})
+
+	sparql := SPARQLClient{}
+	sparql.Client = httpClient
+	sparql.ClientInit("http://example.com", testQuery)
+	sparql.SetAcceptHeader("application/sparql-results+xml")
+	res, err := sparql.SPARQLGo()
+	if err != nil {
+		t.Fatalf("Unexpected error from SPARQLGo: %s", err)
+	}
+	if len(res.Results.Bindings) != 2 {
+		t.Errorf("Expected 2 results, received %d", len(res.Results.Bindings))
+	}
+}
@@ -0,0 +1,253 @@
+package spargotest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ross-spencer/spargo/pkg/spargo"
+)
+
+// Mode determines whether a Cassette talks to the network.
+type Mode int
+
+// Modes a Cassette can run in.
+const (
+	// ModeReplay serves recorded responses without using the network.
+	// Requests that haven't been recorded fail with UnrecordedError.
+	ModeReplay Mode = iota
+	// ModeRecord sends requests to the endpoint and records the
+	// responses, replacing any recorded earlier.
+	ModeRecord
+)
+
+// Interaction is a request and the response to it, as stored in a
+// cassette's fixture files.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest describes a request stored in a cassette.
+type RecordedRequest struct {
+	Method string `json:"method"`
+	// URL is the URL the request was sent to, without any password
+	// included in it.
+	URL    string      `json:"url"`
+	Header http.Header `json:"header"`
+	Query  string      `json:"query"`
+}
+
+// RecordedResponse describes a response stored in a cassette.
+type RecordedResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+	Body   string      `json:"body"`
+}
+
+// UnrecordedError is returned in ModeReplay for a request that hasn't
+// been recorded.
+type UnrecordedError struct {
+	Query string
+	Path  string
+}
+
+// Error enables UnrecordedError to implement the Errors interface.
+func (err UnrecordedError) Error() string {
+	return fmt.Sprintf("spargotest: no recorded response for query, expected in '%s': %s", err.Path, err.Query)
+}
+
+// redactedHeaders are the headers RedactAuth removes.
+var redactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// RedactAuth replaces the values of headers carrying credentials, e.g.
+// Authorization, so that they are not written to fixture files. It is
+// the default Redact function of a Cassette.
+func RedactAuth(interaction *Interaction) {
+	for _, header := range []http.Header{interaction.Request.Header, interaction.Response.Header} {
+		for _, name := range redactedHeaders {
+			if _, ok := header[name]; ok {
+				header.Set(name, "[redacted]")
+			}
+		}
+	}
+}
+
+// Cassette is a http.RoundTripper that records the responses to
+// SPARQL requests as fixture files in a directory, and replays them,
+// so that tests against real endpoints can be run offline. It is used
+// as the transport of a SPARQLClient's http.Client:
+//
+//	cassette, err := spargotest.NewCassette("testdata/wikidata", spargotest.ModeReplay)
+//	...
+//	sparqlMe.Client = &http.Client{Transport: cassette}
+//
+// Requests are matched on their endpoint, Accept header, dataset and
+// query, with runs of white space outside the strings, IRIs and
+// comments of the query treated as a single space, but not on how the
+// query was sent.
+type Cassette struct {
+	// Transport sends requests in ModeRecord. http.DefaultTransport is
+	// used if it is nil.
+	Transport http.RoundTripper
+	// Redact is called on each interaction before it is written to
+	// disk, and can remove or replace anything that shouldn't be kept,
+	// e.g. API keys. It defaults to RedactAuth.
+	Redact func(interaction *Interaction)
+
+	dir  string
+	mode Mode
+}
+
+// NewCassette returns a Cassette storing fixtures in dir. In ModeRecord
+// the directory is created if it doesn't exist.
+func NewCassette(dir string, mode Mode) (*Cassette, error) {
+	if mode == ModeRecord {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, err
+		}
+	}
+	return &Cassette{Redact: RedactAuth, dir: dir, mode: mode}, nil
+}
+
+// RoundTrip records or replays the response to a request.
+func (cassette *Cassette) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	received, err := parseRequest(req, body)
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(cassette.dir, fixtureKey(req, received)+".json")
+
+	if cassette.mode == ModeReplay {
+		return replay(req, received, path)
+	}
+
+	outgoing := req.Clone(req.Context())
+	outgoing.Body = ioutil.NopCloser(bytes.NewReader(body))
+	transport := cassette.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	resp, err := transport.RoundTrip(outgoing)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+
+	interaction := Interaction{
+		Request: RecordedRequest{
+			Method: req.Method,
+			URL:    withoutPassword(req.URL),
+			Header: req.Header.Clone(),
+			Query:  received.Query,
+		},
+		Response: RecordedResponse{
+			Status: resp.StatusCode,
+			Header: resp.Header.Clone(),
+			Body:   string(respBody),
+		},
+	}
+	if cassette.Redact != nil {
+		cassette.Redact(&interaction)
+	}
+	data, err := json.MarshalIndent(interaction, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(path, data, 0o644); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// withoutPassword returns a URL with any password in it removed, so
+// that it isn't written to a fixture file.
+func withoutPassword(address *url.URL) string {
+	stripped := *address
+	if stripped.User != nil {
+		stripped.User = url.User(stripped.User.Username())
+	}
+	return stripped.String()
+}
+
+// replay returns the response recorded for a request.
+func replay(req *http.Request, received Request, path string) (*http.Response, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, UnrecordedError{Query: received.Query, Path: path}
+	}
+	if err != nil {
+		return nil, err
+	}
+	var interaction Interaction
+	if err := json.Unmarshal(data, &interaction); err != nil {
+		return nil, fmt.Errorf("spargotest: reading fixture '%s': %w", path, err)
+	}
+	recorded := interaction.Response
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.Status, http.StatusText(recorded.Status)),
+		StatusCode:    recorded.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        recorded.Header,
+		Body:          ioutil.NopCloser(strings.NewReader(recorded.Body)),
+		ContentLength: int64(len(recorded.Body)),
+		Request:       req,
+	}, nil
+}
+
+// fixtureKey identifies a request regardless of how the query was
+// encoded, so that a fixture can be found again.
+func fixtureKey(req *http.Request, received Request) string {
+	endpoint := *req.URL
+	params := endpoint.Query()
+	for _, name := range []string{"query", "update", "default-graph-uri", "named-graph-uri", "using-graph-uri", "using-named-graph-uri"} {
+		params.Del(name)
+	}
+	endpoint.RawQuery = params.Encode()
+	endpoint.User = nil
+
+	defaultGraphs := append([]string(nil), received.DefaultGraphURIs...)
+	namedGraphs := append([]string(nil), received.NamedGraphURIs...)
+	sort.Strings(defaultGraphs)
+	sort.Strings(namedGraphs)
+
+	hash := sha256.New()
+	parts := []string{
+		endpoint.String(),
+		req.Header.Get("Accept"),
+		fmt.Sprint(received.Update),
+		strings.Join(defaultGraphs, " "),
+		strings.Join(namedGraphs, " "),
+		spargo.NormalizeSpace(received.Query),
+	}
+	for _, part := range parts {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
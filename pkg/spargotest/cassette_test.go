@@ -0,0 +1,146 @@
+package spargotest
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ross-spencer/spargo/pkg/spargo"
+)
+
+// TestCassette makes sure responses are recorded and then replayed
+// without the endpoint, regardless of how the query is sent.
+func TestCassette(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spargo-cassette")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	server := NewServer()
+	server.Handle(testQuery, Response{Result: &testResult})
+
+	recorder, err := NewCassette(dir, ModeRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sparql := spargo.SPARQLClient{}
+	endpoint := strings.Replace(server.URL, "http://", "http://user:hunter2@", 1)
+	sparql.ClientInit(endpoint+"?format=json", testQuery)
+	sparql.Client = &http.Client{Transport: recorder}
+	sparql.SetBearerToken("hunter2", nil)
+	if _, err := sparql.SPARQLGo(); err != nil {
+		t.Fatalf("Unexpected error recording: %s", err)
+	}
+	server.Close()
+
+	fixtures, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(fixtures) != 1 {
+		t.Fatalf("Expected 1 fixture, found %d", len(fixtures))
+	}
+	data, _ := ioutil.ReadFile(fixtures[0])
+	if strings.Contains(string(data), "hunter2") || !strings.Contains(string(data), "[redacted]") {
+		t.Errorf("Expected the bearer token and URL password to be redacted: %s", data)
+	}
+	if !strings.Contains(string(data), "http://user@") {
+		t.Errorf("Expected the username to be kept in the URL: %s", data)
+	}
+
+	player, err := NewCassette(dir, ModeReplay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sparql.Client = &http.Client{Transport: player}
+	sparql.SetQuery(strings.Replace(testQuery, " ", "\n\t ", -1))
+	sparql.SetMethod(spargo.MethodPostDirect)
+	res, err := sparql.SPARQLGo()
+	if err != nil {
+		t.Fatalf("Unexpected error replaying: %s", err)
+	}
+	if !reflect.DeepEqual(res.Results, testResult.Results) {
+		t.Errorf("Unexpected result replayed: %s", res)
+	}
+
+	sparql.SetQuery("ASK { ?s ?p ?o }")
+	_, err = sparql.SPARQLGo()
+	unrecorded := UnrecordedError{}
+	if !errors.As(err, &unrecorded) || unrecorded.Query != "ASK { ?s ?p ?o }" {
+		t.Errorf("Expected an UnrecordedError, received: %v", err)
+	}
+}
+
+// TestCassetteRedact makes sure a custom redaction hook is applied.
+func TestCassetteRedact(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spargo-cassette")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	server := NewServer()
+	defer server.Close()
+	server.SetDefault(Response{Result: &testResult})
+
+	recorder, _ := NewCassette(dir, ModeRecord)
+	recorder.Redact = func(interaction *Interaction) {
+		RedactAuth(interaction)
+		interaction.Request.Header.Del("X-Api-Key")
+	}
+	sparql := spargo.SPARQLClient{}
+	sparql.ClientInit(server.URL, testQuery)
+	sparql.Client = &http.Client{Transport: recorder}
+	sparql.SetBasicAuth("user", "hunter2")
+	sparql.SetHeader("X-Api-Key", "key-1234")
+	if _, err := sparql.SPARQLGo(); err != nil {
+		t.Fatalf("Unexpected error recording: %s", err)
+	}
+	fixtures, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	data, _ := ioutil.ReadFile(fixtures[0])
+	if strings.Contains(string(data), "key-1234") || strings.Contains(string(data), "Basic ") {
+		t.Errorf("Expected credentials to be redacted: %s", data)
+	}
+	req, _ := server.LastRequest()
+	if req.Header.Get("X-Api-Key") != "key-1234" {
+		t.Errorf("Expected the endpoint to receive the unredacted request")
+	}
+}
+
+// TestCassetteLiterals makes sure queries that differ only in the white
+// space inside a literal are recorded apart.
+func TestCassetteLiterals(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spargo-cassette")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	server := NewServer()
+	defer server.Close()
+	server.SetDefault(Response{Result: &testResult})
+
+	recorder, _ := NewCassette(dir, ModeRecord)
+	sparql := spargo.SPARQLClient{}
+	sparql.ClientInit(server.URL, `SELECT ?s WHERE { ?s ?p ?l FILTER(?l = "a  b") }`)
+	sparql.Client = &http.Client{Transport: recorder}
+	if _, err := sparql.SPARQLGo(); err != nil {
+		t.Fatalf("Unexpected error recording: %s", err)
+	}
+
+	player, _ := NewCassette(dir, ModeReplay)
+	sparql.Client = &http.Client{Transport: player}
+	sparql.SetQuery("SELECT ?s\nWHERE { ?s ?p ?l FILTER(?l = \"a  b\") }")
+	if _, err := sparql.SPARQLGo(); err != nil {
+		t.Errorf("Expected a change of layout to be replayed: %s", err)
+	}
+	sparql.SetQuery(`SELECT ?s WHERE { ?s ?p ?l FILTER(?l = "a b") }`)
+	_, err = sparql.SPARQLGo()
+	unrecorded := UnrecordedError{}
+	if !errors.As(err, &unrecorded) {
+		t.Errorf("Expected an UnrecordedError, received: %v", err)
+	}
+}
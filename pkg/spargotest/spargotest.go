@@ -0,0 +1,302 @@
+/*
+Package spargotest provides a fake SPARQL endpoint for testing code that
+uses spargo.
+
+The endpoint is scripted with the responses to give for each query, and
+records the requests it receives so that they can be inspected:
+
+	server := spargotest.NewServer()
+	defer server.Close()
+	server.Handle("SELECT ?s WHERE { ?s ?p ?o }", spargotest.Response{Result: &res})
+
+	sparqlMe := spargo.SPARQLClient{}
+	sparqlMe.ClientInit(server.URL, "SELECT ?s WHERE { ?s ?p ?o }")
+	...
+	req, _ := server.LastRequest()
+*/
+package spargotest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ross-spencer/spargo/pkg/spargo"
+)
+
+// Media types understood or returned by the fake endpoint.
+const (
+	formContentType    string = "application/x-www-form-urlencoded"
+	queryContentType   string = "application/sparql-query"
+	updateContentType  string = "application/sparql-update"
+	resultsContentType string = "application/sparql-results+json"
+	graphContentType   string = "application/n-triples"
+)
+
+// Response describes how the fake endpoint answers a request.
+type Response struct {
+	// Status is the status code of the response. 200 is used if it is
+	// zero.
+	Status int
+	// Header holds additional response headers, e.g. Retry-After.
+	Header http.Header
+	// Result, if set, is sent as SPARQL JSON results.
+	Result *spargo.SPARQLResult
+	// Graph, if set, is sent as N-Triples.
+	Graph spargo.Graph
+	// Body is sent if neither Result nor Graph are set, with
+	// ContentType as its content type.
+	Body        string
+	ContentType string
+	// Delay holds back the response, e.g. to test timeouts. The delay
+	// ends early if the client gives up on the request.
+	Delay time.Duration
+}
+
+// Request is a request received by the fake endpoint.
+type Request struct {
+	Method string
+	URL    *url.URL
+	Header http.Header
+	Body   string
+	// Query is the query, or update, sent with the request however it
+	// was encoded. Update is true if it was sent as an update.
+	Query  string
+	Update bool
+	// DefaultGraphURIs and NamedGraphURIs describe the dataset sent
+	// with a query.
+	DefaultGraphURIs []string
+	NamedGraphURIs   []string
+}
+
+// UserAgent returns the User-Agent header of the request.
+func (req Request) UserAgent() string {
+	return req.Header.Get("User-Agent")
+}
+
+// Accept returns the Accept header of the request.
+func (req Request) Accept() string {
+	return req.Header.Get("Accept")
+}
+
+// rule matches queries to the responses given for them.
+type rule struct {
+	match     func(query string) bool
+	responses []Response
+	served    int
+}
+
+// next returns the next response for the rule. Once every response
+// has been given the last is repeated.
+func (rule *rule) next() Response {
+	index := rule.served
+	if index >= len(rule.responses) {
+		index = len(rule.responses) - 1
+	}
+	rule.served++
+	return rule.responses[index]
+}
+
+// Server is a fake SPARQL endpoint running on a local address, given by
+// URL. It is safe for concurrent use.
+type Server struct {
+	*httptest.Server
+
+	mutex    sync.Mutex
+	rules    []*rule
+	fallback Response
+	requests []Request
+}
+
+// NewServer starts a fake SPARQL endpoint. Queries that don't match a
+// rule are answered with 400 Bad Request until SetDefault is called.
+// The server should be closed once the test has finished.
+func NewServer() *Server {
+	server := &Server{
+		fallback: Response{Status: http.StatusBadRequest, Body: "spargotest: no response for query"},
+	}
+	server.Server = httptest.NewServer(http.HandlerFunc(server.serveHTTP))
+	return server
+}
+
+// Handle gives the responses for a query matching query exactly, once
+// leading and trailing white space is ignored. The responses are given
+// in turn to successive requests, with the last repeated, so that e.g.
+// a retry can be tested with a 503 followed by a result. Rules are
+// checked in the order they were added.
+func (server *Server) Handle(query string, responses ...Response) {
+	query = strings.TrimSpace(query)
+	server.addRule(func(received string) bool {
+		return strings.TrimSpace(received) == query
+	}, responses)
+}
+
+// HandleRegexp gives the responses for queries matching a regular
+// expression, as Handle does. It panics if pattern cannot be compiled.
+func (server *Server) HandleRegexp(pattern string, responses ...Response) {
+	expression := regexp.MustCompile(pattern)
+	server.addRule(expression.MatchString, responses)
+}
+
+// addRule adds a rule to the server.
+func (server *Server) addRule(match func(string) bool, responses []Response) {
+	if len(responses) == 0 {
+		responses = []Response{{}}
+	}
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	server.rules = append(server.rules, &rule{match: match, responses: responses})
+}
+
+// SetDefault sets the response to queries that don't match a rule.
+func (server *Server) SetDefault(response Response) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	server.fallback = response
+}
+
+// Requests returns the requests received so far, in order.
+func (server *Server) Requests() []Request {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	return append([]Request(nil), server.requests...)
+}
+
+// LastRequest returns the most recent request received, and false if
+// there hasn't been one.
+func (server *Server) LastRequest() (Request, bool) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	if len(server.requests) == 0 {
+		return Request{}, false
+	}
+	return server.requests[len(server.requests)-1], true
+}
+
+// Reset removes every rule and forgets the requests received.
+func (server *Server) Reset() {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	server.rules = nil
+	server.requests = nil
+}
+
+// serveHTTP records a request and answers it.
+func (server *Server) serveHTTP(writer http.ResponseWriter, req *http.Request) {
+	received, err := readRequest(req)
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	server.mutex.Lock()
+	server.requests = append(server.requests, received)
+	response := server.fallback
+	for _, rule := range server.rules {
+		if rule.match(received.Query) {
+			response = rule.next()
+			break
+		}
+	}
+	server.mutex.Unlock()
+
+	if response.Delay > 0 {
+		select {
+		case <-time.After(response.Delay):
+		case <-req.Context().Done():
+			return
+		}
+	}
+	writeResponse(writer, response)
+}
+
+// readRequest extracts the query and dataset from a request however it
+// was sent, following the SPARQL 1.1 Protocol.
+func readRequest(req *http.Request) (Request, error) {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return Request{}, err
+	}
+	return parseRequest(req, body)
+}
+
+// parseRequest extracts the query and dataset from a request whose body
+// has already been read.
+func parseRequest(req *http.Request, body []byte) (Request, error) {
+	received := Request{
+		Method: req.Method,
+		URL:    req.URL,
+		Header: req.Header,
+		Body:   string(body),
+	}
+	params := req.URL.Query()
+	media, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	switch {
+	case req.Method == http.MethodGet:
+	case media == formContentType:
+		form, err := url.ParseQuery(received.Body)
+		if err != nil {
+			return Request{}, err
+		}
+		for name, values := range form {
+			params[name] = append(params[name], values...)
+		}
+	case media == queryContentType:
+		params.Set("query", received.Body)
+	case media == updateContentType:
+		params.Set("update", received.Body)
+	default:
+		return Request{}, fmt.Errorf("spargotest: unsupported request content type: '%s'", media)
+	}
+	received.Query = params.Get("query")
+	if update, ok := params["update"]; ok {
+		received.Query = update[0]
+		received.Update = true
+		received.DefaultGraphURIs = params["using-graph-uri"]
+		received.NamedGraphURIs = params["using-named-graph-uri"]
+	} else {
+		received.DefaultGraphURIs = params["default-graph-uri"]
+		received.NamedGraphURIs = params["named-graph-uri"]
+	}
+	return received, nil
+}
+
+// writeResponse sends a scripted response.
+func writeResponse(writer http.ResponseWriter, response Response) {
+	body := []byte(response.Body)
+	contentType := response.ContentType
+	switch {
+	case response.Result != nil:
+		encoded, err := json.Marshal(response.Result)
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		body = encoded
+		contentType = resultsContentType
+	case response.Graph != nil:
+		body = []byte(response.Graph.String())
+		contentType = graphContentType
+	}
+	header := writer.Header()
+	for name, values := range response.Header {
+		header[name] = values
+	}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	status := response.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	writer.WriteHeader(status)
+	writer.Write(body)
+}
@@ -0,0 +1,146 @@
+package spargotest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ross-spencer/spargo/pkg/spargo"
+)
+
+const testQuery = "SELECT ?puid WHERE { ?format <http://www.wikidata.org/prop/direct/P2748> ?puid }"
+
+var testResult = spargo.SPARQLResult{
+	Head: map[string]interface{}{"vars": []interface{}{"puid"}},
+	Results: spargo.Binding{Bindings: []map[string]spargo.Item{
+		{"puid": {Type: "literal", Value: "fmt/43"}},
+	}},
+}
+
+// TestServerQuery makes sure scripted results are returned and that
+// requests are recorded along with the headers set by ClientInit.
+func TestServerQuery(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	server.Handle("  "+testQuery+"\n", Response{Result: &testResult})
+
+	for _, method := range []spargo.RequestMethod{spargo.MethodGet, spargo.MethodPostForm, spargo.MethodPostDirect} {
+		sparql := spargo.SPARQLClient{}
+		sparql.ClientInit(server.URL, testQuery)
+		sparql.SetMethod(method)
+		sparql.SetDataset(nil, []string{"http://example.com/graph/pronom"})
+		res, err := sparql.SPARQLGo()
+		if err != nil {
+			t.Fatalf("Unexpected error for %s: %s", method, err)
+		}
+		if !reflect.DeepEqual(res.Results, testResult.Results) {
+			t.Errorf("Unexpected result for %s: %s", method, res)
+		}
+		req, ok := server.LastRequest()
+		if !ok {
+			t.Fatalf("Expected the request to be recorded")
+		}
+		if req.Query != testQuery || req.Update {
+			t.Errorf("Unexpected query recorded for %s: %s", method, req.Query)
+		}
+		if req.UserAgent() != spargo.DefaultAgent || req.Accept() != spargo.DefaultAccept {
+			t.Errorf("Unexpected headers recorded for %s: %v", method, req.Header)
+		}
+		if len(req.NamedGraphURIs) != 1 || req.NamedGraphURIs[0] != "http://example.com/graph/pronom" {
+			t.Errorf("Unexpected dataset recorded for %s: %v", method, req.NamedGraphURIs)
+		}
+	}
+	if len(server.Requests()) != 3 {
+		t.Errorf("Expected 3 requests, received %d", len(server.Requests()))
+	}
+}
+
+// TestServerSequence makes sure responses are given in turn, so that
+// retries can be tested, and that unmatched queries fail.
+func TestServerSequence(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	header := http.Header{"Retry-After": []string{"0"}}
+	server.HandleRegexp(`(?i)^SELECT`,
+		Response{Status: http.StatusServiceUnavailable, Header: header},
+		Response{Result: &testResult},
+	)
+
+	sparql := spargo.SPARQLClient{}
+	sparql.ClientInit(server.URL, testQuery)
+	sparql.SetRetryPolicy(&spargo.RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond})
+	if _, err := sparql.SPARQLGo(); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(server.Requests()) != 2 {
+		t.Errorf("Expected 2 requests, received %d", len(server.Requests()))
+	}
+
+	sparql.SetQuery("ASK { ?s ?p ?o }")
+	_, err := sparql.SPARQLGo()
+	responseErr := spargo.ResponseError{}
+	if !errors.As(err, &responseErr) || responseErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unmatched query, received: %v", err)
+	}
+
+	answer := true
+	server.SetDefault(Response{Result: &spargo.SPARQLResult{Boolean: &answer}})
+	if res, err := sparql.Ask(); err != nil || !res {
+		t.Errorf("Expected the default response, received: %t %v", res, err)
+	}
+
+	server.Reset()
+	if len(server.Requests()) != 0 {
+		t.Errorf("Expected requests to be forgotten")
+	}
+}
+
+// TestServerUpdateAndGraph makes sure updates are recorded and graphs
+// can be scripted.
+func TestServerUpdateAndGraph(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	graph := spargo.Graph{{
+		Subject:   spargo.IRI("http://example.com/jpeg"),
+		Predicate: spargo.IRI("http://example.com/puid"),
+		Object:    spargo.Literal{Value: "fmt/43"},
+	}}
+	server.HandleRegexp(`^CONSTRUCT`, Response{Graph: graph})
+	server.HandleRegexp(`^INSERT`, Response{Status: http.StatusNoContent})
+
+	sparql := spargo.SPARQLClient{}
+	sparql.ClientInit(server.URL, "CONSTRUCT WHERE { ?s ?p ?o }")
+	res, err := sparql.SPARQLGraph()
+	if err != nil || len(res) != 1 || res[0].Object.String() != `"fmt/43"` {
+		t.Errorf("Unexpected graph: %v %v", res, err)
+	}
+
+	update := "INSERT DATA { <http://example.com/s> <http://example.com/p> 1 }"
+	options := spargo.UpdateOptions{UsingGraphURIs: []string{"http://example.com/g"}, Direct: true}
+	if err := sparql.SPARQLUpdate(update, options); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	req, _ := server.LastRequest()
+	if !req.Update || req.Query != update || len(req.DefaultGraphURIs) != 1 {
+		t.Errorf("Unexpected update recorded: %+v", req)
+	}
+}
+
+// TestServerDelay makes sure a delayed response can be used to test
+// timeouts.
+func TestServerDelay(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	server.Handle(testQuery, Response{Result: &testResult, Delay: time.Second})
+
+	sparql := spargo.SPARQLClient{}
+	sparql.ClientInit(server.URL, testQuery)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := sparql.SPARQLGoContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the query to time out, received: %v", err)
+	}
+}